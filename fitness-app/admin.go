@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireAPIKey guards admin routes with the API_KEY env var, sent as
+// X-API-Key or a bearer token. Without API_KEY the routes are disabled.
+func requireAPIKey(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin endpoints are disabled; set API_KEY to enable them"})
+			return
+		}
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			key = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing API key"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// POST /api/v1/maintenance/analyze[?vacuum=true]
+func analyzeDatabase(repo AdminRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		vacuum, err := parseFlexBool(c.Query("vacuum"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "vacuum: " + err.Error()})
+			return
+		}
+		tables, err := repo.Tables()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		command := "ANALYZE"
+		if vacuum {
+			command = "VACUUM ANALYZE"
+		}
+
+		start := time.Now()
+		results := make([]gin.H, 0, len(tables))
+		for _, table := range tables {
+			tableStart := time.Now()
+			if err := repo.Analyze(c.Request.Context(), table, vacuum); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "table": table, "completed": results})
+				return
+			}
+			results = append(results, gin.H{"table": table, "duration_ms": time.Since(tableStart).Milliseconds()})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"command":     command,
+			"tables":      results,
+			"duration_ms": time.Since(start).Milliseconds(),
+		})
+	}
+}
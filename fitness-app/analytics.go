@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Load moved by a set in SQL, kept in step with totalLoad
+const loadSQL = "(CASE WHEN per_side THEN weight * 2 ELSE weight END)"
+
+// volumeSQL is a set's volume in SQL, kept in step with workoutVolume
+func (c Config) volumeSQL() string {
+	return fmt.Sprintf("(CASE WHEN set_type = '%s' THEN 0 ELSE "+loadSQL+" * (reps + forced_reps * %g + partial_reps * %g) END)", warmupSetType, c.ForcedRepFraction, c.PartialRepFraction)
+}
+
+// totalLoad is the weight moved per rep across both sides, so unilateral
+// sets aren't undercounted against bilateral ones.
+func totalLoad(w Workout) float64 {
+	if w.PerSide {
+		return float64(w.Weight) * 2
+	}
+	return float64(w.Weight)
+}
+
+// Training volume (tonnage) of a single set. Forced and partial reps count
+// as a configurable fraction of a full rep; warm-ups count as none.
+func (c Config) workoutVolume(w Workout) float64 {
+	if w.SetType == warmupSetType {
+		return 0
+	}
+	reps := float64(w.Reps) + float64(w.ForcedReps)*c.ForcedRepFraction + float64(w.PartialReps)*c.PartialRepFraction
+	return totalLoad(w) * reps
+}
+
+// repsLabel renders reps for the cards, e.g. "8 + 2 forced"
+func repsLabel(w Workout) string {
+	label := fmt.Sprint(w.Reps)
+	if w.IsAMRAP {
+		label += "+" // 5/3/1 style: as many as possible
+	}
+	if w.ForcedReps > 0 {
+		label += fmt.Sprintf(" + %d forced", w.ForcedReps)
+	}
+	if w.PartialReps > 0 {
+		label += fmt.Sprintf(" + %d partial", w.PartialReps)
+	}
+	return label
+}
+
+// trackedName is the exercise with its variation, e.g. "Bench (close grip)".
+// Sets with a variation are grouped and shown apart from the plain exercise.
+func trackedName(w Workout) string {
+	if w.Variation == "" {
+		return w.Exercise
+	}
+	return fmt.Sprintf("%s (%s)", w.Exercise, w.Variation)
+}
+
+// weightLabel renders the weight for the cards, e.g. "20.0kg/side"
+func weightLabel(w Workout) string {
+	label := fmt.Sprintf("%.1fkg", w.Weight)
+	if w.PerSide {
+		label += "/side"
+	}
+	return label
+}
+
+// Sets with no RPE logged are assumed to be working sets; warm-ups never
+// are, whatever their RPE
+func (c Config) isWorkingSet(w Workout) bool {
+	if w.SetType == warmupSetType {
+		return false
+	}
+	return w.RPE == 0 || w.RPE >= c.WorkingSetMinRPE
+}
+
+func (c Config) totalVolume(workouts []Workout) float64 {
+	total := 0.0
+	for _, w := range workouts {
+		total += c.workoutVolume(w)
+	}
+	return total
+}
+
+// workloadRatio is the acute:chronic workload ratio as of now, over the
+// last 28 days of sets. ok is false until there's a full chronic window.
+func workloadRatio(repo WorkoutRepository, config Config, recent []Workout, now time.Time) (acute, chronic, ratio float64, ok bool, err error) {
+	acuteStart := now.AddDate(0, 0, -7)
+	chronicTotal := 0.0
+	for _, w := range recent {
+		v := config.workoutVolume(w)
+		chronicTotal += v
+		if w.CreatedAt.After(acuteStart) {
+			acute += v
+		}
+	}
+	chronic = chronicTotal / 4
+
+	// A ratio is only meaningful once there's a full chronic window
+	first, err := repo.ListWorkouts(WorkoutQuery{Limit: 1})
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	if len(first) == 0 || first[0].CreatedAt.After(now.AddDate(0, 0, -28)) || chronic == 0 {
+		return acute, chronic, 0, false, nil
+	}
+	return acute, chronic, acute / chronic, true, nil
+}
+
+// GET /api/v1/acwr
+// Acute load is the last 7 days of volume, chronic load the weekly average
+// over the last 28 days, of the sets passing set_type/exclude_set_type
+// (warm-ups carry no volume either way). Both windows are fixed; ?to= (or
+// days/period, whose end is now) picks the day they end on.
+func getACWR(repo WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, now, err := parseWindow(c, 28)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		types, err := config.setTypeFilters(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		recent, err := repo.ListWorkouts(WorkoutQuery{Filters: types, From: now.AddDate(0, 0, -28), To: now})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		acute, chronic, ratio, ok, err := workloadRatio(repo, config, recent, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusOK, gin.H{
+				"acute_load":   Weight(acute),
+				"chronic_load": Weight(chronic),
+				"ratio":        nil,
+				"risky":        false,
+				"message":      "Need at least 28 days of history",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"acute_load":   Weight(acute),
+			"chronic_load": Weight(chronic),
+			"ratio":        ratio,
+			"threshold":    config.ACWRRiskThreshold,
+			"risky":        ratio > config.ACWRRiskThreshold,
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Each RPE point is roughly one rep in reserve, or about 3% of load
+const loadPerRPE = 0.03
+
+type NextSetAdvice struct {
+	Action    string `json:"action"` // "increase", "decrease" or "repeat"
+	Weight    Weight `json:"weight"`
+	Reps      int    `json:"reps"`
+	TargetRPE int    `json:"target_rpe"`
+	Message   string `json:"message"`
+}
+
+// nextSetAdvice compares the logged RPE with the target and suggests the
+// next set, rounding any new weight with mode. Sets logged without an RPE
+// get no advice.
+func nextSetAdvice(w Workout, targetRPE int, mode roundingMode, config Config) *NextSetAdvice {
+	if w.RPE <= 0 {
+		return nil
+	}
+
+	advice := &NextSetAdvice{Weight: w.Weight, Reps: w.Reps, TargetRPE: targetRPE}
+	diff := targetRPE - w.RPE
+	if diff == 0 {
+		advice.Action = "repeat"
+		advice.Message = fmt.Sprintf("Right on RPE %d, repeat %.1fkg x %d", targetRPE, w.Weight, w.Reps)
+		return advice
+	}
+
+	advice.Action = "increase"
+	if diff < 0 {
+		advice.Action = "decrease"
+	}
+
+	// Prefer adjusting load; fall back to reps when the change is smaller
+	// than a plate jump or there's no external load.
+	weight := config.roundToLoadable(float64(w.Weight)*(1+loadPerRPE*float64(diff)), mode)
+	if w.Weight > 0 && weight != float64(w.Weight) && weight > 0 {
+		advice.Weight = Weight(weight)
+		advice.Message = fmt.Sprintf("RPE %d vs target %d: %s to %.1fkg x %d", w.RPE, targetRPE, advice.Action, weight, w.Reps)
+		return advice
+	}
+	advice.Reps = int(math.Max(1, float64(w.Reps+diff)))
+	advice.Message = fmt.Sprintf("RPE %d vs target %d: %s to %d reps @ %.1fkg", w.RPE, targetRPE, advice.Action, advice.Reps, w.Weight)
+	return advice
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FlexBool accepts the many ways clients spell booleans (yes/no, on/off, 1/0,
+// true/false) in both form posts and JSON bodies.
+type FlexBool bool
+
+func parseFlexBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("cannot parse %q as a boolean (use true/false, yes/no, on/off or 1/0)", s)
+}
+
+// UnmarshalParam is used by gin's form binding
+func (b *FlexBool) UnmarshalParam(param string) error {
+	v, err := parseFlexBool(param)
+	if err != nil {
+		return err
+	}
+	*b = FlexBool(v)
+	return nil
+}
+
+func (b *FlexBool) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch v := raw.(type) {
+	case bool:
+		*b = FlexBool(v)
+		return nil
+	case nil:
+		*b = false
+		return nil
+	case float64:
+		return b.UnmarshalParam(fmt.Sprint(v))
+	case string:
+		return b.UnmarshalParam(v)
+	}
+	return fmt.Errorf("cannot parse %s as a boolean", data)
+}
+
+// Body types ShouldBind understands
+var bindableContentTypes = []string{"application/json", "application/x-www-form-urlencoded", "multipart/form-data"}
+
+// requireBindableBody rejects write requests whose body ShouldBind can't
+// read (e.g. text/plain, which it silently treats as an empty form) with a
+// 415. Bodiless writes like POST /program/advance pass through, as do
+// paths under the exempt prefixes, whose handlers read the raw body.
+func requireBindableBody(exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+		for _, prefix := range exempt {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+		if c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+		raw := c.GetHeader("Content-Type")
+		mediaType, _, err := mime.ParseMediaType(raw)
+		if err == nil {
+			for _, t := range bindableContentTypes {
+				if mediaType == t {
+					c.Next()
+					return
+				}
+			}
+		}
+		if raw == "" {
+			raw = "none"
+		}
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": fmt.Sprintf("unsupported Content-Type %s; send %s", raw, strings.Join(bindableContentTypes, ", ")),
+		})
+	}
+}
+
+var registerValidationsOnce sync.Once
+
+// registerValidations adds the struct-level rules tags can't express to
+// gin's validator. Safe to call from every newRouter.
+func registerValidations() {
+	registerValidationsOnce.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			v.RegisterStructValidation(workoutStructLevel, Workout{})
+		}
+	})
+}
+
+// workoutStructLevel requires a weight except on bodyweight movements
+// (pull-ups, push-ups), where 0 is the honest value. It also rejects an
+// exercise or muscle group of only spaces, which binding:"required" lets
+// through.
+func workoutStructLevel(sl validator.StructLevel) {
+	w := sl.Current().Interface().(Workout)
+	if w.Weight == 0 && !isBodyweight(w.Equipment) {
+		sl.ReportError(w.Weight, "Weight", "weight", "required", "")
+	}
+	if strings.TrimSpace(w.Exercise) == "" {
+		sl.ReportError(w.Exercise, "Exercise", "exercise", "notblank", "")
+	}
+	if w.MuscleGroup != "" && strings.TrimSpace(w.MuscleGroup) == "" {
+		sl.ReportError(w.MuscleGroup, "MuscleGroup", "muscle_group", "notblank", "")
+	}
+}
+
+func isBodyweight(equipment string) bool {
+	return strings.EqualFold(strings.TrimSpace(equipment), "bodyweight")
+}
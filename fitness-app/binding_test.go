@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWorkoutStructLevel(t *testing.T) {
+	app := newTestApp(t, nil)
+	tests := []struct {
+		name   string
+		body   gin.H
+		status int
+	}{
+		{"bodyweight set without a weight", gin.H{"exercise": "Pull-up", "reps": 10, "equipment": "bodyweight"}, http.StatusCreated},
+		{"bodyweight matches case and spacing", gin.H{"exercise": "Push-up", "reps": 20, "equipment": " Bodyweight "}, http.StatusCreated},
+		{"bodyweight set with added load", gin.H{"exercise": "Dip", "reps": 8, "weight": 10, "equipment": "bodyweight"}, http.StatusCreated},
+		{"loaded set without a weight", gin.H{"exercise": "Squat", "reps": 5, "equipment": "barbell"}, http.StatusBadRequest},
+		{"loaded set with a zero weight", gin.H{"exercise": "Squat", "reps": 5, "weight": 0, "equipment": "barbell"}, http.StatusBadRequest},
+		{"set without equipment or weight", gin.H{"exercise": "Squat", "reps": 5}, http.StatusBadRequest},
+		{"loaded set with a weight", gin.H{"exercise": "Squat", "reps": 5, "weight": 100, "equipment": "barbell"}, http.StatusCreated},
+		{"exercise of only spaces", gin.H{"exercise": "   ", "reps": 5, "weight": 100}, http.StatusBadRequest},
+		{"muscle group of only spaces", gin.H{"exercise": "Squat", "reps": 5, "weight": 100, "muscle_group": " "}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app.t = t
+			app.reset()
+			rec := app.do(http.MethodPost, "/api/v1/workout", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
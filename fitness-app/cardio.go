@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cardio is a conditioning session. It lives apart from Workout so strength
+// analytics never see it; only training-day counts include it.
+type Cardio struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Type            string    `gorm:"index" json:"type" form:"type"` // e.g. "run", "row", "bike"
+	DurationSeconds int       `json:"duration_seconds" form:"duration_seconds"`
+	DistanceKm      float64   `json:"distance_km" form:"distance_km"` // Optional
+	AvgHR           int       `json:"avg_hr" form:"avg_hr"`           // Optional, bpm
+	CreatedAt       time.Time `json:"timestamp"`
+}
+
+func validateCardio(entry *Cardio) error {
+	entry.Type = strings.TrimSpace(entry.Type)
+	switch {
+	case entry.Type == "":
+		return fmt.Errorf("type is required")
+	case entry.DurationSeconds <= 0:
+		return fmt.Errorf("duration_seconds must be positive")
+	case entry.DistanceKm < 0:
+		return fmt.Errorf("distance_km must not be negative")
+	case entry.AvgHR != 0 && (entry.AvgHR < 30 || entry.AvgHR > 250):
+		return fmt.Errorf("avg_hr must be between 30 and 250 bpm")
+	}
+	return nil
+}
+
+// bindCardio reads and validates a cardio entry from the request body
+func bindCardio(c *gin.Context) (Cardio, bool) {
+	var entry Cardio
+	if err := c.ShouldBind(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return entry, false
+	}
+	if err := validateCardio(&entry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return entry, false
+	}
+	return entry, true
+}
+
+// findCardio loads the entry named by the :id path parameter, writing the
+// error response if there isn't one
+func findCardio(c *gin.Context, repo CardioRepository) (Cardio, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return Cardio{}, false
+	}
+	entry, err := repo.GetCardio(uint(id))
+	if err == errNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no cardio entry " + c.Param("id")})
+		return entry, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return entry, false
+	}
+	return entry, true
+}
+
+// POST /api/v1/cardio
+func logCardio(repo CardioRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entry, ok := bindCardio(c)
+		if !ok {
+			return
+		}
+		entry.ID, entry.CreatedAt = 0, time.Now()
+		if err := repo.CreateCardio(&entry); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, entry)
+	}
+}
+
+// GET /api/v1/cardio, newest first
+func listCardio(repo CardioRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entries, err := repo.ListCardio()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, entries)
+	}
+}
+
+// GET /api/v1/cardio/:id
+func getCardio(repo CardioRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if entry, ok := findCardio(c, repo); ok {
+			c.JSON(http.StatusOK, entry)
+		}
+	}
+}
+
+// PUT /api/v1/cardio/:id replaces an entry, keeping its timestamp
+func putCardio(repo CardioRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		existing, ok := findCardio(c, repo)
+		if !ok {
+			return
+		}
+		entry, ok := bindCardio(c)
+		if !ok {
+			return
+		}
+		entry.ID, entry.CreatedAt = existing.ID, existing.CreatedAt
+		if err := repo.SaveCardio(&entry); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, entry)
+	}
+}
+
+// DELETE /api/v1/cardio/:id
+func deleteCardio(repo CardioRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entry, ok := findCardio(c, repo)
+		if !ok {
+			return
+		}
+		if _, err := repo.DeleteCardio(entry.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Config is every setting the app reads from the environment, loaded and
+// validated once at startup by loadConfig.
+type Config struct {
+	Port   string // PORT
+	AppEnv string // APP_ENV: "development" enables the /api/v1/dev routes
+	APIKey string // API_KEY: enables and guards admin endpoints
+
+	EnablePprof bool // ENABLE_PPROF: serve /debug/pprof, behind the API key
+
+	DB DBConfig
+
+	WeightPrecision      int                 // WEIGHT_PRECISION: decimals shown for weights in responses
+	ACWRRiskThreshold    float64             // ACWR_RISK_THRESHOLD: acute:chronic ratio flagged as risky
+	RPERiseThreshold     float64             // RPE_RISE_THRESHOLD: rise in average RPE over the prior 3 weeks flagged as overreaching
+	MetricsReminderDays  int                 // METRICS_REMINDER_DAYS: measurement cadence before nudging
+	LoadIncrement        float64             // LOAD_INCREMENT: smallest plate jump, in kg
+	LoadRounding         roundingMode        // ROUNDING_MODE: how suggested weights snap to LoadIncrement
+	MicroIncrement       float64             // MICRO_INCREMENT: fractional plate jump for microloading exercises, in kg
+	TargetRPE            int                 // TARGET_RPE: intended effort for auto-regulation advice
+	ProgressionTieBreak  string              // PROGRESSION_TIE_BREAK: "weight" or "reps" when a set earns either
+	MuscleRecoveryHours  int                 // MUSCLE_RECOVERY_HOURS: minimum rest before training a muscle again
+	TMIncrementUpper     float64             // TM_INCREMENT_UPPER: training max bump per cycle, upper body
+	TMIncrementLower     float64             // TM_INCREMENT_LOWER: training max bump per cycle, lower body
+	ForcedRepFraction    float64             // FORCED_REP_VOLUME: share of a full rep a forced rep adds to volume
+	PartialRepFraction   float64             // PARTIAL_REP_VOLUME: share of a full rep a partial rep adds to volume
+	DefaultStartWeight   float64             // DEFAULT_START_WEIGHT: first target for unconfigured exercises (empty bar)
+	DefaultStartReps     int                 // DEFAULT_START_REPS
+	TargetDebounceMillis int                 // TARGET_DEBOUNCE_MS: reuse an identical /target result this long
+	WorkingSetMinRPE     int                 // WORKING_SET_MIN_RPE: easier sets count as warm-ups
+	FailureImpliesRPE10  bool                // FAILURE_IMPLIES_RPE10: failure sets logged without RPE get RPE 10
+	WeightJumpWarnPct    float64             // WEIGHT_JUMP_WARN_PCT: warn past this % over the last set; 0 disables
+	DuplicateWindowSecs  int                 // DUPLICATE_WINDOW_SECONDS: warn on an identical set this soon; 0 disables
+	UnitCheck            bool                // UNIT_CHECK: warn when a weight looks like lbs entered as kg, or the reverse
+	VolumeLandmarks      map[string]landmark // VOLUME_LANDMARKS: weekly MEV/MAV/MRV sets per muscle group
+	SessionNames         map[string]string   // SESSION_NAMES: muscle group to suggested session name
+	SetTypes             []string            // SET_TYPES: allowed set_type values; must include "working"
+
+	JSONFieldNaming string // JSON_FIELD_NAMING: "snake" or "camel" response keys
+
+	AnalyticsLookbackDays int // ANALYTICS_LOOKBACK_DAYS: default window when a request gives none; 0 means all history
+	AnalyticsConcurrency  int // ANALYTICS_CONCURRENCY: most queries the week review runs at once
+
+	SeedExerciseConfigs bool   // SEED_EXERCISE_CONFIGS: insert starter configs into an empty table
+	ExerciseSeedFile    string // EXERCISE_SEED_FILE: optional JSON list replacing the built-in starters
+
+	// Opt-in cap for small hosts: keep each exercise's last N training days,
+	// folding older sets into exercise_archives
+	HistoryCapEnabled  bool // HISTORY_CAP_ENABLED
+	HistoryCapSessions int  // HISTORY_CAP_SESSIONS
+}
+
+type DBConfig struct {
+	Driver    string // DB_DRIVER: "postgres" or "memory" (nothing persisted)
+	URL       string // DATABASE_URL, preferred over the discrete DB_* settings
+	Host      string // DB_HOST
+	Port      string // DB_PORT
+	User      string // DB_USER
+	Password  string // DB_PASS
+	Name      string // DB_NAME
+	BatchSize int    // DB_BATCH_SIZE: rows per round-trip for batched reads and inserts
+}
+
+// defaultConfig is every setting at its default, e.g. for tests and tools
+func defaultConfig() Config {
+	c, _ := loadConfig(func(string) string { return "" })
+	return c
+}
+
+// loadConfig reads and validates every setting, reporting all problems at
+// once rather than stopping at the first.
+func loadConfig(getenv func(string) string) (Config, error) {
+	r := &envReader{getenv: getenv}
+	c := Config{
+		Port:   r.String("PORT", "8081"),
+		AppEnv: r.String("APP_ENV", "production"),
+		APIKey: r.String("API_KEY", ""),
+
+		EnablePprof: r.Bool("ENABLE_PPROF", false),
+
+		DB: DBConfig{
+			Driver:    strings.ToLower(r.String("DB_DRIVER", "postgres")),
+			URL:       r.String("DATABASE_URL", ""),
+			Host:      r.String("DB_HOST", ""),
+			Port:      r.String("DB_PORT", ""),
+			User:      r.String("DB_USER", ""),
+			Password:  r.String("DB_PASS", ""),
+			Name:      r.String("DB_NAME", ""),
+			BatchSize: r.PositiveInt("DB_BATCH_SIZE", 500),
+		},
+
+		WeightPrecision:      r.Int("WEIGHT_PRECISION", 1),
+		ACWRRiskThreshold:    r.Float("ACWR_RISK_THRESHOLD", 1.5),
+		RPERiseThreshold:     r.Float("RPE_RISE_THRESHOLD", 1.0),
+		MetricsReminderDays:  r.PositiveInt("METRICS_REMINDER_DAYS", 7),
+		LoadIncrement:        r.Float("LOAD_INCREMENT", 2.5),
+		LoadRounding:         r.RoundingMode("ROUNDING_MODE", roundNearest),
+		MicroIncrement:       r.Float("MICRO_INCREMENT", 0.5),
+		TargetRPE:            r.Int("TARGET_RPE", 8),
+		ProgressionTieBreak:  strings.ToLower(r.String("PROGRESSION_TIE_BREAK", "weight")),
+		MuscleRecoveryHours:  r.Int("MUSCLE_RECOVERY_HOURS", 48),
+		TMIncrementUpper:     r.Float("TM_INCREMENT_UPPER", 2.5),
+		TMIncrementLower:     r.Float("TM_INCREMENT_LOWER", 5),
+		ForcedRepFraction:    r.Float("FORCED_REP_VOLUME", 0.5),
+		PartialRepFraction:   r.Float("PARTIAL_REP_VOLUME", 0.5),
+		DefaultStartWeight:   r.Float("DEFAULT_START_WEIGHT", 20),
+		DefaultStartReps:     r.PositiveInt("DEFAULT_START_REPS", 8),
+		TargetDebounceMillis: r.Int("TARGET_DEBOUNCE_MS", 300),
+		WorkingSetMinRPE:     r.Int("WORKING_SET_MIN_RPE", 6),
+		FailureImpliesRPE10:  r.Bool("FAILURE_IMPLIES_RPE10", true),
+		WeightJumpWarnPct:    r.Float("WEIGHT_JUMP_WARN_PCT", 20),
+		DuplicateWindowSecs:  r.Int("DUPLICATE_WINDOW_SECONDS", 60),
+		UnitCheck:            r.Bool("UNIT_CHECK", false),
+		VolumeLandmarks:      r.Landmarks("VOLUME_LANDMARKS", defaultLandmarks),
+		SessionNames:         r.SessionNames("SESSION_NAMES", defaultSessionNames),
+		SetTypes:             r.SetTypes("SET_TYPES", defaultSetTypes),
+
+		JSONFieldNaming: strings.ToLower(r.String("JSON_FIELD_NAMING", "snake")),
+
+		AnalyticsLookbackDays: r.Int("ANALYTICS_LOOKBACK_DAYS", 365),
+		AnalyticsConcurrency:  r.PositiveInt("ANALYTICS_CONCURRENCY", 3),
+
+		SeedExerciseConfigs: r.Bool("SEED_EXERCISE_CONFIGS", false),
+		ExerciseSeedFile:    r.String("EXERCISE_SEED_FILE", ""),
+
+		HistoryCapEnabled:  r.Bool("HISTORY_CAP_ENABLED", false),
+		HistoryCapSessions: r.PositiveInt("HISTORY_CAP_SESSIONS", 500),
+	}
+
+	if c.DB.Driver != "postgres" && c.DB.Driver != "memory" {
+		r.fail("DB_DRIVER must be postgres or memory, got %q", c.DB.Driver)
+	}
+	if c.JSONFieldNaming != "snake" && c.JSONFieldNaming != "camel" {
+		r.fail("JSON_FIELD_NAMING must be snake or camel, got %q", c.JSONFieldNaming)
+	}
+	if c.ProgressionTieBreak != "weight" && c.ProgressionTieBreak != "reps" {
+		r.fail("PROGRESSION_TIE_BREAK must be weight or reps, got %q", c.ProgressionTieBreak)
+	}
+	if c.TargetDebounceMillis < 0 {
+		r.fail("TARGET_DEBOUNCE_MS must not be negative, got %d", c.TargetDebounceMillis)
+	}
+	if c.AnalyticsLookbackDays < 0 {
+		r.fail("ANALYTICS_LOOKBACK_DAYS must not be negative, got %d", c.AnalyticsLookbackDays)
+	}
+	if c.WeightPrecision < 0 {
+		r.fail("WEIGHT_PRECISION must not be negative, got %d", c.WeightPrecision)
+	}
+	if c.LoadIncrement < 0 {
+		r.fail("LOAD_INCREMENT must not be negative, got %g", c.LoadIncrement)
+	}
+	if c.MicroIncrement <= 0 {
+		r.fail("MICRO_INCREMENT must be positive, got %g", c.MicroIncrement)
+	}
+	if c.TargetRPE < 1 || c.TargetRPE > 10 {
+		r.fail("TARGET_RPE must be between 1 and 10, got %d", c.TargetRPE)
+	}
+	if c.WorkingSetMinRPE < 0 || c.WorkingSetMinRPE > 10 {
+		r.fail("WORKING_SET_MIN_RPE must be between 0 and 10, got %d", c.WorkingSetMinRPE)
+	}
+	if c.DB.URL != "" {
+		if err := validateDatabaseURL(c.DB.URL); err != nil {
+			r.fail("%v", err)
+		}
+	}
+
+	if len(r.errs) > 0 {
+		return c, errors.New("invalid configuration:\n  " + strings.Join(r.errs, "\n  "))
+	}
+	return c, nil
+}
+
+// Redacted is the config with secrets masked, for logging at startup
+func (c Config) Redacted() Config {
+	mask := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "***"
+	}
+	c.APIKey = mask(c.APIKey)
+	c.DB.Password = mask(c.DB.Password)
+	if u, err := url.Parse(c.DB.URL); err == nil && c.DB.URL != "" {
+		c.DB.URL = u.Redacted()
+	}
+	return c
+}
+
+// envReader parses settings, collecting every problem instead of stopping
+type envReader struct {
+	getenv func(string) string
+	errs   []string
+}
+
+func (r *envReader) fail(format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func (r *envReader) String(key, fallback string) string {
+	if v := r.getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func (r *envReader) Int(key string, fallback int) int {
+	raw := r.getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		r.fail("%s must be an integer, got %q", key, raw)
+		return fallback
+	}
+	return v
+}
+
+func (r *envReader) PositiveInt(key string, fallback int) int {
+	v := r.Int(key, fallback)
+	if v <= 0 {
+		r.fail("%s must be positive, got %d", key, v)
+		return fallback
+	}
+	return v
+}
+
+func (r *envReader) Float(key string, fallback float64) float64 {
+	raw := r.getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		r.fail("%s must be a number, got %q", key, raw)
+		return fallback
+	}
+	return v
+}
+
+func (r *envReader) Bool(key string, fallback bool) bool {
+	raw := r.getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	v, err := parseFlexBool(raw)
+	if err != nil {
+		r.fail("%s: %v", key, err)
+		return fallback
+	}
+	return v
+}
+
+func (r *envReader) RoundingMode(key string, fallback roundingMode) roundingMode {
+	raw := r.getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	mode, err := parseRoundingMode(raw)
+	if err != nil {
+		r.fail("%s: %v", key, err)
+		return fallback
+	}
+	return mode
+}
+
+func (r *envReader) Landmarks(key string, fallback map[string]landmark) map[string]landmark {
+	landmarks, err := parseLandmarks(r.getenv(key), fallback)
+	if err != nil {
+		r.fail("%s: %v", key, err)
+		return fallback
+	}
+	return landmarks
+}
+
+func (r *envReader) SetTypes(key string, fallback []string) []string {
+	types, err := parseSetTypes(r.getenv(key), fallback)
+	if err != nil {
+		r.fail("%s: %v", key, err)
+		return fallback
+	}
+	return types
+}
+
+func (r *envReader) SessionNames(key string, fallback map[string]string) map[string]string {
+	names, err := parseSessionNames(r.getenv(key), fallback)
+	if err != nil {
+		r.fail("%s: %v", key, err)
+		return fallback
+	}
+	return names
+}
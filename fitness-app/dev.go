@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST /api/v1/dev/reset?confirm=true[&seed=true]
+// Wipes every table for a clean slate, optionally re-inserting the starter
+// exercise configs (from seedFile when set).
+func resetData(repo Repository, seedFile string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		confirm, err := parseFlexBool(c.Query("confirm"))
+		if err != nil || !confirm {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "this deletes all data; pass confirm=true"})
+			return
+		}
+		seed, err := parseFlexBool(c.Query("seed"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "seed: " + err.Error()})
+			return
+		}
+
+		if err := repo.Reset(c.Request.Context()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var seeded int64
+		if seed {
+			if seeded, err = seedExerciseConfigs(repo, seedFile); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"reset": true, "seeded_exercise_configs": seeded})
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// One step of a drop set, done straight after the main set without rest
+type DropInput struct {
+	Weight Weight `json:"weight" binding:"required"`
+	Reps   int    `json:"reps" binding:"required"`
+}
+
+// Body for POST /api/v1/workout: a set plus any drops chained onto it
+type workoutRequest struct {
+	Workout
+	Drops []DropInput `json:"drops" form:"-" binding:"dive"`
+}
+
+func validateDrops(w Workout, drops []DropInput) error {
+	prev := w.Weight
+	for i, d := range drops {
+		if d.Weight >= prev {
+			return fmt.Errorf("drops[%d]: weight must be lower than the set before it", i)
+		}
+		if d.Reps <= 0 {
+			return fmt.Errorf("drops[%d]: reps must be positive", i)
+		}
+		prev = d.Weight
+	}
+	return nil
+}
+
+// dropSetType is "drop" for the rows after a drop set's first, unless
+// SET_TYPES leaves it out
+func dropSetType(config Config) string {
+	if t, err := config.normalizeSetType("drop"); err == nil {
+		return t
+	}
+	return defaultSetType
+}
+
+// createWithDrops stores the main set and its drops as linked rows sharing a
+// DropSetID (the main set's ID), all or nothing.
+func createWithDrops(repo WorkoutRepository, w *Workout, drops []DropInput, config Config) ([]Workout, error) {
+	var created []Workout
+	for _, d := range drops {
+		created = append(created, Workout{
+			Exercise:    w.Exercise,
+			Variation:   w.Variation,
+			Reps:        d.Reps,
+			Weight:      d.Weight,
+			MuscleGroup: w.MuscleGroup,
+			Equipment:   w.Equipment,
+			Tempo:       w.Tempo,
+			IsFailure:   w.IsFailure,
+			PerSide:     w.PerSide,
+			Tags:        w.Tags,
+			SetType:     dropSetType(config),
+		})
+	}
+	if err := repo.CreateWorkout(w, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// isDropFollower is whether w is one of the drops after a drop set's first
+// set, rather than a set of its own
+func isDropFollower(w Workout) bool {
+	return w.DropSetID != 0 && w.DropSetID != w.ID
+}
+
+// groupDrops maps each drop set leader's ID to the drops that followed it
+func groupDrops(workouts []Workout) map[uint][]Workout {
+	groups := map[uint][]Workout{}
+	for _, w := range workouts {
+		if isDropFollower(w) {
+			groups[w.DropSetID] = append(groups[w.DropSetID], w)
+		}
+	}
+	for id := range groups {
+		sort.Slice(groups[id], func(i, j int) bool { return groups[id][i].ID < groups[id][j].ID })
+	}
+	return groups
+}
+
+// dropChainLabel renders "100.0kg x 8 → 80.0kg x 6 → 60.0kg x 5"
+func dropChainLabel(w Workout, drops []Workout) string {
+	label := fmt.Sprintf("%s x %s", weightLabel(w), repsLabel(w))
+	for _, d := range drops {
+		label += fmt.Sprintf(" → %s x %d", weightLabel(d), d.Reps)
+	}
+	return label
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDropSetVariation(t *testing.T) {
+	app := newTestApp(t, nil)
+	app.seed(Workout{Exercise: "Bench", Weight: 80, Reps: 8, CreatedAt: time.Now().AddDate(0, 0, -2)})
+
+	rec := app.do(http.MethodPost, "/api/v1/workout", gin.H{
+		"exercise": "Bench", "variation": "close grip", "weight": 100, "reps": 8,
+		"drops": []gin.H{{"weight": 80, "reps": 6}, {"weight": 60, "reps": 5}},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var target struct {
+		Message     string `json:"message"`
+		NewExercise bool   `json:"new_exercise"`
+	}
+	decode(t, app.do(http.MethodGet, "/api/v1/target?exercise=Bench", nil), &target)
+	if target.Message != "Last: 80.0kg x 8" {
+		t.Errorf("plain target message = %q, want the plain set's", target.Message)
+	}
+
+	decode(t, app.do(http.MethodGet, "/api/v1/target?exercise=Bench&variation="+url.QueryEscape("close grip"), nil), &target)
+	if target.NewExercise {
+		t.Errorf("variation target has no history")
+	}
+
+	var sets []Workout
+	decode(t, app.do(http.MethodGet, "/api/v1/workouts?exercise=Bench&variation="+url.QueryEscape("close grip"), nil), &sets)
+	if len(sets) != 3 {
+		t.Errorf("variation has %d sets, want the set and both drops", len(sets))
+	}
+}
+
+func TestTargetFromTopSet(t *testing.T) {
+	app := newTestApp(t, nil)
+	rec := app.do(http.MethodPost, "/api/v1/workout", gin.H{
+		"exercise": "Bench", "weight": 100, "reps": 8,
+		"drops": []gin.H{{"weight": 80, "reps": 6}, {"weight": 60, "reps": 5}},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = app.do(http.MethodPost, "/api/v1/workout", gin.H{"exercise": "Bench", "weight": 40, "reps": 10, "set_type": "warmup"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var target struct {
+		Message string `json:"message"`
+	}
+	decode(t, app.do(http.MethodGet, "/api/v1/target?exercise=Bench", nil), &target)
+	if target.Message != "Last: 100.0kg x 8" {
+		t.Errorf("target message = %q, want it from the 100kg top set", target.Message)
+	}
+}
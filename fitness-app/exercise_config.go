@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Per-exercise settings that persist across sets
+type ExerciseConfig struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Exercise string `gorm:"uniqueIndex" json:"exercise"`
+	Cue      string `json:"cue" form:"cue"` // Technique reminder, e.g. "brace, leg drive"
+
+	MuscleGroup string `json:"muscle_group" form:"muscle_group"`
+	Equipment   string `json:"equipment" form:"equipment"`
+	RepRangeMin int    `json:"rep_range_min" form:"rep_range_min"`
+	RepRangeMax int    `json:"rep_range_max" form:"rep_range_max"`
+	Increment   Weight `json:"increment" form:"increment"` // Load jump when progressing, in kg
+	// Multiplier on the load jump, e.g. 2 for fast-progressing accessories
+	ProgressionRate float64 `gorm:"default:1" json:"progression_rate" form:"progression_rate"`
+	IsFavorite      bool    `json:"is_favorite" form:"is_favorite"` // Pinned to the top of the exercise picker
+	// Intended effort for auto-regulation, e.g. 8 for heavy compounds and 9
+	// for accessories. Zero falls back to TARGET_RPE.
+	TargetRPE int `json:"target_rpe" form:"target_rpe"`
+	// Optional family this exercise is a variation of, e.g. "Squat" for
+	// Low-Bar and High-Bar Squat, for combined progression
+	VariationGroup string `gorm:"index" json:"variation_group" form:"variation_group"`
+	// "microload" adds a rep and MICRO_INCREMENT together instead of a full
+	// plate jump; empty is the standard weight-or-reps progression
+	Progression string `json:"progression" form:"progression"`
+	// Shorthand that queries resolve to this exercise, e.g. "deads"
+	Aliases Tags `json:"aliases" form:"aliases"`
+
+	// Presentation only: card accent and a short glyph shown before the name
+	Color string `json:"color" form:"color"` // Hex, e.g. "#ef4444"
+	Icon  string `json:"icon" form:"icon"`   // e.g. "🏋️"
+
+	// Suggested first target before any sets are logged
+	StartingWeight Weight `json:"starting_weight" form:"starting_weight"`
+	StartingReps   int    `json:"starting_reps" form:"starting_reps"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// startingTarget is the target for an exercise with no history: the config's
+// starting point if set, otherwise the global defaults.
+func (c Config) startingTarget(cfg ExerciseConfig) (Weight, int) {
+	weight, reps := Weight(c.DefaultStartWeight), c.DefaultStartReps
+	if cfg.StartingWeight > 0 {
+		weight = cfg.StartingWeight
+	}
+	if cfg.StartingReps > 0 {
+		reps = cfg.StartingReps
+	}
+	return weight, reps
+}
+
+// progressionStep is how much the target weight goes up after a successful
+// set: the configured (or default 2.5kg) increment times the progression
+// rate, snapped to loadable plates but never below the smallest jump.
+func (c Config) progressionStep(cfg ExerciseConfig) Weight {
+	base, rate := 2.5, 1.0
+	if cfg.Increment > 0 {
+		base = float64(cfg.Increment)
+	}
+	if cfg.ProgressionRate > 0 {
+		rate = cfg.ProgressionRate
+	}
+	step := c.roundToLoadable(base*rate, roundNearest)
+	if step <= 0 {
+		step = c.LoadIncrement
+	}
+	return Weight(step)
+}
+
+func (c Config) exerciseTargetRPE(cfg ExerciseConfig) int {
+	if cfg.TargetRPE > 0 {
+		return cfg.TargetRPE
+	}
+	return c.TargetRPE
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validateColor trims the color and rejects anything but hex, since it ends
+// up in a style attribute
+func (cfg *ExerciseConfig) validateColor() error {
+	cfg.Color = strings.TrimSpace(cfg.Color)
+	if cfg.Color != "" && !hexColor.MatchString(cfg.Color) {
+		return fmt.Errorf("color must be a hex string like #ef4444, got %s", cfg.Color)
+	}
+	return nil
+}
+
+// BeforeSave holds seeds and onboarding to the same color rule as the PUT
+func (cfg *ExerciseConfig) BeforeSave(tx *gorm.DB) error {
+	return cfg.validateColor()
+}
+
+// cardAccent is the inline style giving a card the exercise's color, or
+// nothing to keep the default border.
+func cardAccent(cfg ExerciseConfig) string {
+	if cfg.Color == "" {
+		return ""
+	}
+	return fmt.Sprintf(` style="border-left-color: %s"`, html.EscapeString(cfg.Color))
+}
+
+// exerciseLabel is the set's exercise as the cards show it, escaped: icon
+// first, then the name with any variation
+func exerciseLabel(w Workout, cfg ExerciseConfig) string {
+	name := html.EscapeString(trackedName(w))
+	if cfg.Icon == "" {
+		return name
+	}
+	return html.EscapeString(cfg.Icon) + " " + name
+}
+
+// resolveExercise maps a name or alias (case-insensitive) to the canonical
+// exercise name. An exact config name wins; unknown names come back as-is.
+func resolveExercise(configs ExerciseConfigRepository, name string) string {
+	if _, ok := findExerciseConfig(configs, name); ok {
+		return name
+	}
+	all, err := configs.ListExerciseConfigs()
+	if err != nil {
+		return name
+	}
+	alias := normalizeTag(name)
+	for _, cfg := range all {
+		if cfg.Aliases.Has(alias) {
+			return cfg.Exercise
+		}
+	}
+	return name
+}
+
+// canonicalExercise resolves a request's exercise and reports the name it
+// queried in the X-Exercise header
+func canonicalExercise(c *gin.Context, configs ExerciseConfigRepository, name string) string {
+	if name == "" {
+		return name
+	}
+	canonical := resolveExercise(configs, name)
+	c.Header("X-Exercise", canonical)
+	return canonical
+}
+
+// aliasConflict is the first of cfg's aliases already naming another
+// exercise, either outright or as one of its aliases
+func aliasConflict(configs ExerciseConfigRepository, cfg ExerciseConfig) (string, string, error) {
+	all, err := configs.ListExerciseConfigs()
+	if err != nil {
+		return "", "", err
+	}
+	for _, alias := range cfg.Aliases {
+		for _, other := range all {
+			if other.Exercise == cfg.Exercise {
+				continue
+			}
+			if normalizeTag(other.Exercise) == alias || other.Aliases.Has(alias) {
+				return alias, other.Exercise, nil
+			}
+		}
+	}
+	return "", "", nil
+}
+
+func findExerciseConfig(repo ExerciseConfigRepository, exercise string) (ExerciseConfig, bool) {
+	cfg, err := repo.FindExerciseConfig(exercise)
+	return cfg, err == nil
+}
+
+// GET /api/v1/exercise-configs
+func listExerciseConfigs(repo ExerciseConfigRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		configs, _ := repo.ListExerciseConfigs()
+		c.JSON(http.StatusOK, configs)
+	}
+}
+
+// GET /api/v1/exercise-configs/:exercise
+func getExerciseConfig(repo ExerciseConfigRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg, ok := findExerciseConfig(repo, c.Param("exercise"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no config for " + c.Param("exercise")})
+			return
+		}
+		c.JSON(http.StatusOK, cfg)
+	}
+}
+
+// PUT /api/v1/exercise-configs/:exercise creates or replaces the config
+func putExerciseConfig(repo ExerciseConfigRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input ExerciseConfig
+		if err := c.ShouldBind(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if input.ProgressionRate < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "progression_rate must not be negative"})
+			return
+		}
+		if input.TargetRPE < 0 || input.TargetRPE > 10 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_rpe must be between 1 and 10, or 0 for the default"})
+			return
+		}
+		input.Icon = strings.TrimSpace(input.Icon)
+		if err := input.validateColor(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		input.Progression = strings.ToLower(strings.TrimSpace(input.Progression))
+		if input.Progression != "" && input.Progression != "microload" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "progression must be microload or empty, got " + input.Progression})
+			return
+		}
+		if input.ProgressionRate == 0 {
+			input.ProgressionRate = 1
+		}
+		exercise := strings.TrimSpace(c.Param("exercise"))
+		if exercise == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exercise must not be blank"})
+			return
+		}
+
+		cfg, existed := findExerciseConfig(repo, exercise)
+		input.ID, input.CreatedAt = cfg.ID, cfg.CreatedAt
+		input.Exercise = exercise
+		input.VariationGroup = strings.TrimSpace(input.VariationGroup)
+		if input.Aliases == nil {
+			input.Aliases = Tags{}
+		}
+		alias, owner, err := aliasConflict(repo, input)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if alias != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("alias %q already refers to %s", alias, owner)})
+			return
+		}
+		if err := repo.SaveExerciseConfig(&input); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		status := http.StatusOK
+		if !existed {
+			status = http.StatusCreated
+		}
+		c.JSON(status, input)
+	}
+}
+
+// DELETE /api/v1/exercise-configs/:exercise
+func deleteExerciseConfig(repo ExerciseConfigRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deleted, err := repo.DeleteExerciseConfig(c.Param("exercise"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no config for " + c.Param("exercise")})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// POST /api/v1/maintenance/sync-muscle-groups[?dry_run=true]
+// Copies each config's muscle group onto that exercise's logged sets where
+// it's blank or different. A dry run reports the count without writing.
+func syncMuscleGroups(repo WorkoutRepository, configs ExerciseConfigRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dryRun, err := parseFlexBool(c.Query("dry_run"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run: " + err.Error()})
+			return
+		}
+		all, err := configs.ListExerciseConfigs()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		updates := map[uint]string{}
+		exercises := map[string]int{}
+		for _, cfg := range all {
+			group := strings.TrimSpace(cfg.MuscleGroup)
+			if group == "" {
+				continue
+			}
+			workouts, err := repo.ListWorkouts(exerciseQuery(cfg.Exercise))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			for _, w := range workouts {
+				if w.MuscleGroup != group {
+					updates[w.ID] = group
+					exercises[cfg.Exercise]++
+				}
+			}
+		}
+		if !dryRun {
+			if err := repo.UpdateMuscleGroups(updates); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"updated": len(updates), "exercises": exercises, "dry_run": dryRun})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var volumeComparisons = []struct {
+	Name string
+	Kg   float64
+}{
+	{"blue whale", 150000},
+	{"school bus", 12000},
+	{"African elephant", 6000},
+	{"car", 1500},
+	{"grand piano", 450},
+}
+
+func volumeEquivalent(kg float64) string {
+	for _, cmp := range volumeComparisons {
+		if kg >= cmp.Kg {
+			return fmt.Sprintf("%.1f × %s", kg/cmp.Kg, cmp.Name)
+		}
+	}
+	return ""
+}
+
+// GET /api/v1/experience[?days=N|from=...&to=...|period=...]
+// Training age counts weeks with at least one session, so time off doesn't
+// inflate it. Full history by default; a window narrows every figure to it.
+func getExperience(repo WorkoutRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, err := parseWindow(c, 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		stats, err := repo.WorkoutSummary(from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		daysSinceFirst := 0
+		if stats.FirstLog != nil {
+			daysSinceFirst = int(time.Since(*stats.FirstLog).Hours() / 24)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"total_sessions":     stats.Sessions,
+			"total_sets":         stats.Sets,
+			"first_log":          stats.FirstLog,
+			"days_since_first":   daysSinceFirst,
+			"active_weeks":       stats.ActiveWeeks,
+			"training_age_years": float64(stats.ActiveWeeks) / 52,
+			"total_volume":       Weight(stats.Volume),
+			"equivalent":         volumeEquivalent(stats.Volume),
+		})
+	}
+}
+
+// Lifetime volume milestones in kg, ascending
+var volumeMilestones = []float64{10000, 50000, 100000, 250000, 500000, 1000000, 2500000, 5000000, 10000000}
+
+type volumeMilestone struct {
+	Threshold   Weight  `json:"threshold"`
+	Label       string  `json:"label"`
+	Remaining   Weight  `json:"remaining,omitempty"`
+	ProgressPct float64 `json:"progress_pct"`
+}
+
+func milestoneLabel(kg float64) string {
+	if kg >= 1000000 {
+		return fmt.Sprintf("%gM kg", kg/1000000)
+	}
+	return fmt.Sprintf("%gk kg", kg/1000)
+}
+
+// GET /api/v1/milestones
+// Lifetime volume against fixed milestones, for a progress-bar widget. The
+// total comes from one aggregate query plus the archived history, the same
+// as /experience.
+func getMilestones(repo WorkoutRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats, err := repo.WorkoutSummary(time.Time{}, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		crossed, upcoming := []volumeMilestone{}, []volumeMilestone{}
+		for _, kg := range volumeMilestones {
+			m := volumeMilestone{Threshold: Weight(kg), Label: milestoneLabel(kg), ProgressPct: 100}
+			if stats.Volume >= kg {
+				crossed = append(crossed, m)
+				continue
+			}
+			m.Remaining = Weight(kg - stats.Volume)
+			m.ProgressPct = math.Round(stats.Volume/kg*1000) / 10
+			upcoming = append(upcoming, m)
+		}
+
+		resp := gin.H{
+			"total_volume": Weight(stats.Volume),
+			"crossed":      crossed,
+			"upcoming":     upcoming,
+		}
+		if len(upcoming) > 0 {
+			resp["next"] = upcoming[0]
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
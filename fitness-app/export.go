@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// With ?anonymize=true exports drop everything that could identify the lifter
+// or carry free text, keeping the numeric training data:
+//   - row ids (workouts and metrics)
+//   - workout tags, variation and tempo, all typed freely
+//   - metrics notes
+//
+// Exercise, muscle group and equipment stay: without them the numbers mean
+// nothing.
+var (
+	anonymizedWorkoutFields = []string{"id", "tags", "variation", "tempo"}
+	anonymizedMetricsFields = []string{"id", "notes"}
+)
+
+var workoutCSVHeader = []string{"id", "timestamp", "exercise", "reps", "forced_reps", "partial_reps", "weight", "rpe", "tempo", "muscle_group", "equipment", "is_failure", "per_side", "is_pr", "tags", "variation", "is_amrap", "set_type"}
+
+func workoutCSVRow(w Workout, precision int) []string {
+	return []string{
+		strconv.FormatUint(uint64(w.ID), 10),
+		w.CreatedAt.Format(time.RFC3339),
+		w.Exercise,
+		strconv.Itoa(w.Reps),
+		strconv.Itoa(w.ForcedReps),
+		strconv.Itoa(w.PartialReps),
+		strconv.FormatFloat(w.Weight.Round(precision), 'f', -1, 64),
+		strconv.Itoa(w.RPE),
+		w.Tempo,
+		w.MuscleGroup,
+		w.Equipment,
+		strconv.FormatBool(bool(w.IsFailure)),
+		strconv.FormatBool(bool(w.PerSide)),
+		strconv.FormatBool(w.IsPR),
+		strings.Join(w.Tags, ","),
+		w.Variation,
+		strconv.FormatBool(bool(w.IsAMRAP)),
+		w.SetType,
+	}
+}
+
+var metricsCSVHeader = []string{"id", "timestamp", "shoulder_circumference", "waist_circumference", "chest_circumference", "bodyweight",
+	"arm_circumference", "thigh_circumference", "calf_circumference", "hip_circumference", "neck_circumference", "notes"}
+
+func metricsCSVRow(m BodyMetrics) []string {
+	return []string{
+		strconv.FormatUint(uint64(m.ID), 10),
+		m.CreatedAt.Format(time.RFC3339),
+		strconv.FormatFloat(m.ShoulderCircumference, 'f', -1, 64),
+		strconv.FormatFloat(m.WaistCircumference, 'f', -1, 64),
+		strconv.FormatFloat(m.ChestCircumference, 'f', -1, 64),
+		strconv.FormatFloat(m.Bodyweight, 'f', -1, 64),
+		strconv.FormatFloat(m.ArmCircumference, 'f', -1, 64),
+		strconv.FormatFloat(m.ThighCircumference, 'f', -1, 64),
+		strconv.FormatFloat(m.CalfCircumference, 'f', -1, 64),
+		strconv.FormatFloat(m.HipCircumference, 'f', -1, 64),
+		strconv.FormatFloat(m.NeckCircumference, 'f', -1, 64),
+		m.Notes,
+	}
+}
+
+// dropColumns removes the named columns from a CSV header and its rows
+func dropColumns(header []string, drop []string) (kept []string, keep func([]string) []string) {
+	var idx []int
+	for i, h := range header {
+		skip := false
+		for _, d := range drop {
+			if h == d {
+				skip = true
+			}
+		}
+		if !skip {
+			idx = append(idx, i)
+			kept = append(kept, h)
+		}
+	}
+	return kept, func(row []string) []string {
+		out := make([]string, len(idx))
+		for j, i := range idx {
+			out[j] = row[i]
+		}
+		return out
+	}
+}
+
+// anonymizeJSON re-encodes a record without the named fields
+func anonymizeJSON(v interface{}, drop []string) (map[string]interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// Numbers stay as written, so weights keep their mark for roundWeights
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	for _, d := range drop {
+		delete(m, d)
+	}
+	return m, nil
+}
+
+type exportOptions struct {
+	Format    string
+	Anonymize bool
+}
+
+func parseExportOptions(c *gin.Context) (exportOptions, error) {
+	opts := exportOptions{Format: c.DefaultQuery("format", "json")}
+	if opts.Format != "json" && opts.Format != "csv" {
+		return opts, fmt.Errorf("format must be json or csv")
+	}
+	anonymize, err := parseFlexBool(c.Query("anonymize"))
+	if err != nil {
+		return opts, fmt.Errorf("anonymize: %w", err)
+	}
+	opts.Anonymize = anonymize
+	return opts, nil
+}
+
+// Trailer naming the error when an export fails part way through
+const exportErrorTrailer = "X-Export-Error"
+
+// exportRows streams a table in batches as CSV or a JSON array. Nothing is
+// written until the first batch is read, so a failure up front is a plain
+// 500. Past that the status is gone: the export stops, the JSON array is
+// left unclosed so it doesn't parse, and the error goes in the
+// X-Export-Error trailer, rather than passing off a short file as whole.
+func exportRows[T any](c *gin.Context, batchSize int, name string, header []string, toRow func(T) []string, drop []string, each func(int, func([]T) error) error) {
+	opts, err := parseExportOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !opts.Anonymize {
+		drop = nil
+	}
+
+	kept, keep := dropColumns(header, drop)
+	var out *csv.Writer
+	enc := json.NewEncoder(c.Writer)
+	started, first := false, true
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("2006-01-02"), opts.Format)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Header("Trailer", exportErrorTrailer)
+		if opts.Format == "csv" {
+			c.Header("Content-Type", "text/csv")
+			out = csv.NewWriter(c.Writer)
+			out.Write(kept)
+			return
+		}
+		c.Header("Content-Type", "application/json")
+		c.Writer.WriteString("[")
+	}
+
+	err = each(batchSize, func(batch []T) error {
+		start()
+		if opts.Format == "csv" {
+			for _, row := range batch {
+				out.Write(keep(toRow(row)))
+			}
+			out.Flush()
+			return out.Error()
+		}
+		for _, row := range batch {
+			var record interface{} = row
+			if len(drop) > 0 {
+				m, err := anonymizeJSON(row, drop)
+				if err != nil {
+					return err
+				}
+				record = m
+			}
+			if !first {
+				c.Writer.WriteString(",")
+			}
+			first = false
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("export %s: stopped part way: %v", name, err)
+		c.Writer.Header().Set(exportErrorTrailer, err.Error())
+		c.Error(err)
+		return
+	}
+
+	start()
+	if opts.Format == "csv" {
+		out.Flush()
+		return
+	}
+	c.Writer.WriteString("]")
+}
+
+// GET /api/v1/export/workouts?format=csv|json[&anonymize=true]
+func exportWorkouts(repo WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		toRow := func(w Workout) []string { return workoutCSVRow(w, config.WeightPrecision) }
+		exportRows(c, config.DB.BatchSize, "workouts", workoutCSVHeader, toRow, anonymizedWorkoutFields, repo.EachWorkoutBatch)
+	}
+}
+
+// GET /api/v1/export/metrics?format=csv|json[&anonymize=true]
+func exportMetrics(repo MetricsRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exportRows(c, config.DB.BatchSize, "metrics", metricsCSVHeader, metricsCSVRow, anonymizedMetricsFields, repo.EachMetricsBatch)
+	}
+}
+
+// exportMeta sums up one exported table without reading it out
+type exportMeta struct {
+	Rows            int64      `json:"rows"`
+	LatestCreatedAt *time.Time `json:"latest_created_at"` // null for an empty table
+	LatestUpdatedAt *time.Time `json:"latest_updated_at"` // null too for rows from before updated_at existed
+	Hash            string     `json:"hash"`              // Changes with any insert, edit or delete
+}
+
+// exportAggregates are the cheap aggregates exportMeta is built from. An
+// insert raises the max id, a delete changes the count and id sum, and an
+// edit raises the latest updated_at.
+type exportAggregates struct {
+	Rows            int64 `gorm:"column:row_count"`
+	MaxID           uint
+	SumID           int64
+	LatestCreatedAt *time.Time
+	LatestUpdatedAt *time.Time
+}
+
+func (a exportAggregates) meta() exportMeta {
+	stamp := func(t *time.Time) int64 {
+		if t == nil {
+			return 0
+		}
+		return t.UnixMicro()
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%d:%d:%d:%d", a.Rows, a.MaxID, a.SumID, stamp(a.LatestCreatedAt), stamp(a.LatestUpdatedAt))))
+	return exportMeta{Rows: a.Rows, LatestCreatedAt: a.LatestCreatedAt, LatestUpdatedAt: a.LatestUpdatedAt, Hash: fmt.Sprintf("%x", sum)}
+}
+
+// GET /api/v1/export/metadata
+// Row counts, newest timestamps and hashes of what the exports cover, so
+// backup tooling can skip a full export when nothing changed. The hashes
+// come from aggregates, not row contents: edits count through updated_at,
+// which PR flag recomputes don't touch since the flags are derived. The
+// combined hash is also the ETag; send it back as If-None-Match to get a
+// 304.
+func exportMetadata(workouts WorkoutRepository, metrics MetricsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w, err := workouts.WorkoutExportMeta()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		m, err := metrics.MetricsExportMeta()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(w.Hash+":"+m.Hash)))
+		etag := `"` + hash + `"`
+		c.Header("ETag", etag)
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"workouts": w,
+			"metrics":  m,
+			"hash":     hash,
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/v1/favorites
+// Pinned exercises by name. HTMX gets <option>s for the exercise picker.
+func listFavorites(repo ExerciseConfigRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		configs, err := repo.ListExerciseConfigs()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		favorites := []ExerciseConfig{}
+		for _, cfg := range configs {
+			if cfg.IsFavorite {
+				favorites = append(favorites, cfg)
+			}
+		}
+
+		if c.GetHeader("HX-Request") == "true" {
+			var options strings.Builder
+			for _, cfg := range favorites {
+				fmt.Fprintf(&options, "<option value=\"%s\">★ favorite</option>\n", html.EscapeString(cfg.Exercise))
+			}
+			c.Writer.Header().Set("Content-Type", "text/html")
+			c.String(http.StatusOK, options.String())
+			return
+		}
+		c.JSON(http.StatusOK, favorites)
+	}
+}
+
+// PUT /api/v1/favorites/:exercise pins an exercise, creating its config if
+// needed. DELETE unpins it.
+func setFavorite(repo ExerciseConfigRepository, favorite bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exercise := strings.TrimSpace(c.Param("exercise"))
+		if exercise == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exercise must not be blank"})
+			return
+		}
+		cfg, ok := findExerciseConfig(repo, exercise)
+		if !ok {
+			if !favorite {
+				c.JSON(http.StatusNotFound, gin.H{"error": "no config for " + exercise})
+				return
+			}
+			cfg = ExerciseConfig{Exercise: exercise, ProgressionRate: 1}
+		}
+
+		cfg.IsFavorite = favorite
+		if err := repo.SaveExerciseConfig(&cfg); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, cfg)
+	}
+}
+
+// An exercise offered in the picker
+type exerciseSuggestion struct {
+	Exercise    string `json:"exercise"`
+	MuscleGroup string `json:"muscle_group"`
+	Equipment   string `json:"equipment"`
+	Favorite    bool   `json:"favorite"`
+}
+
+// favoritesFirst moves pinned exercises to the front, by name, adding any
+// pinned ones not suggested yet; the rest keep their order
+func favoritesFirst(suggestions []exerciseSuggestion, configs []ExerciseConfig) []exerciseSuggestion {
+	pinned := map[string]ExerciseConfig{}
+	for _, cfg := range configs {
+		if cfg.IsFavorite {
+			pinned[cfg.Exercise] = cfg
+		}
+	}
+	favorites, rest := []exerciseSuggestion{}, []exerciseSuggestion{}
+	for _, s := range suggestions {
+		if _, ok := pinned[s.Exercise]; !ok {
+			rest = append(rest, s)
+			continue
+		}
+		delete(pinned, s.Exercise)
+		s.Favorite = true
+		favorites = append(favorites, s)
+	}
+	for _, cfg := range pinned {
+		favorites = append(favorites, exerciseSuggestion{cfg.Exercise, cfg.MuscleGroup, cfg.Equipment, true})
+	}
+	sort.Slice(favorites, func(i, j int) bool { return favorites[i].Exercise < favorites[j].Exercise })
+	return append(favorites, rest...)
+}
+
+// GET /api/v1/exercises/suggestions
+// What to offer in the exercise picker: the starter list (built in, or
+// EXERCISE_SEED_FILE) until anything is logged, then the exercises actually
+// trained, by name. Favorites come first either way. Muscle group and
+// equipment come from the exercise's config, else its last set. HTMX gets
+// <option>s.
+func getExerciseSuggestions(repo WorkoutRepository, configs ExerciseConfigRepository, seedFile string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exercises, err := repo.WorkoutExercises()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		source := "history"
+		suggestions := make([]exerciseSuggestion, 0, len(exercises))
+		if len(exercises) == 0 {
+			source = "starter"
+			starters, err := starterExercises(seedFile)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			for _, cfg := range starters {
+				suggestions = append(suggestions, exerciseSuggestion{cfg.Exercise, cfg.MuscleGroup, cfg.Equipment, false})
+			}
+		} else {
+			for _, exercise := range exercises {
+				s := exerciseSuggestion{Exercise: exercise}
+				if cfg, ok := findExerciseConfig(configs, exercise); ok {
+					s.MuscleGroup, s.Equipment = cfg.MuscleGroup, cfg.Equipment
+				}
+				if s.MuscleGroup == "" || s.Equipment == "" {
+					if last, ok := lastWorkout(repo, exerciseQuery(exercise)); ok {
+						if s.MuscleGroup == "" {
+							s.MuscleGroup = last.MuscleGroup
+						}
+						if s.Equipment == "" {
+							s.Equipment = last.Equipment
+						}
+					}
+				}
+				suggestions = append(suggestions, s)
+			}
+			sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].Exercise < suggestions[j].Exercise })
+		}
+		all, err := configs.ListExerciseConfigs()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		suggestions = favoritesFirst(suggestions, all)
+
+		if c.GetHeader("HX-Request") == "true" {
+			var options strings.Builder
+			for _, s := range suggestions {
+				label := html.EscapeString(s.MuscleGroup)
+				if s.Favorite {
+					label = "★ favorite"
+				}
+				fmt.Fprintf(&options, "<option value=\"%s\">%s</option>\n", html.EscapeString(s.Exercise), label)
+			}
+			c.Writer.Header().Set("Content-Type", "text/html")
+			c.String(http.StatusOK, options.String())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"source": source, "exercises": suggestions})
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Goal is a target estimated 1RM for one exercise
+type Goal struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Exercise    string    `gorm:"uniqueIndex" json:"exercise"`
+	TargetOneRM Weight    `json:"target_1rm"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Profile holds app-wide preferences. There is only ever one row.
+type Profile struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	Unit      string    `json:"unit"` // Unit the lifter thinks in, "kg" or "lbs"; storage is always kg
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const profileID = 1
+
+// Recent pace for goal projections: session bests over this many weeks
+const goalPaceWeeks = 8
+
+type goalProjection struct {
+	TargetOneRM  Weight  `json:"target_1rm"`
+	CurrentOneRM Weight  `json:"current_1rm"` // Best estimated 1RM so far
+	NextOneRM    Weight  `json:"next_1rm"`    // Estimated 1RM of the suggested set
+	Remaining    Weight  `json:"remaining"`   // Still to gain after the suggested set
+	ProgressPct  float64 `json:"progress_pct"`
+	Reached      bool    `json:"reached"`
+	// Trend in session-best e1RM over the last goalPaceWeeks; nil with
+	// fewer than two sessions
+	WeeklyGain    *Weight `json:"weekly_gain"`
+	ProjectedDate *string `json:"projected_date"` // nil when reached or not progressing
+}
+
+// projectGoal compares a suggested set against the exercise's goal and
+// extrapolates recent progress to a date.
+func projectGoal(repo WorkoutRepository, goal Goal, next Workout, now time.Time) goalProjection {
+	current, _ := bestOneRM(repo, goal.Exercise, time.Time{})
+	nextOneRM := workoutOneRM(next)
+	best := max(current, nextOneRM)
+	target := float64(goal.TargetOneRM)
+	p := goalProjection{
+		TargetOneRM:  goal.TargetOneRM,
+		CurrentOneRM: Weight(current),
+		NextOneRM:    Weight(nextOneRM),
+		Remaining:    Weight(max(target-best, 0)),
+		ProgressPct:  math.Round(current/target*1000) / 10,
+		Reached:      best >= target,
+	}
+	if p.Reached {
+		return p
+	}
+
+	q := exerciseQuery(goal.Exercise)
+	q.From = now.AddDate(0, 0, -7*goalPaceWeeks)
+	sets, err := repo.ListWorkouts(q)
+	if err != nil {
+		return p
+	}
+	perDay, ok := dailyTrend(sessionBests(sets))
+	if !ok {
+		return p
+	}
+	weekly := Weight(perDay * 7)
+	p.WeeklyGain = &weekly
+	if perDay > 0 {
+		days := math.Ceil((target - best) / perDay)
+		date := now.AddDate(0, 0, int(days)).Format("2006-01-02")
+		p.ProjectedDate = &date
+	}
+	return p
+}
+
+type dayBest struct {
+	day   time.Time
+	oneRM float64
+}
+
+// sessionBests is the best estimated 1RM of each training day, oldest first
+func sessionBests(sets []Workout) []dayBest {
+	var bests []dayBest
+	for _, w := range sets {
+		e1rm := workoutOneRM(w)
+		if n := len(bests); n > 0 && dayKey(bests[n-1].day) == dayKey(w.CreatedAt) {
+			bests[n-1].oneRM = max(bests[n-1].oneRM, e1rm)
+			continue
+		}
+		bests = append(bests, dayBest{w.CreatedAt, e1rm})
+	}
+	return bests
+}
+
+// dailyTrend is the least-squares slope of the bests in kg per day
+func dailyTrend(bests []dayBest) (float64, bool) {
+	if len(bests) < 2 {
+		return 0, false
+	}
+	n := float64(len(bests))
+	var sx, sy, sxx, sxy float64
+	for _, b := range bests {
+		x := b.day.Sub(bests[0].day).Hours() / 24
+		sx += x
+		sy += b.oneRM
+		sxx += x * x
+		sxy += x * b.oneRM
+	}
+	denom := n*sxx - sx*sx
+	if denom == 0 {
+		return 0, false
+	}
+	return (n*sxy - sx*sy) / denom, true
+}
+
+func findGoal(repo GoalRepository, exercise string) (Goal, bool) {
+	goal, err := repo.FindGoal(exercise)
+	return goal, err == nil
+}
+
+// GET /api/v1/goals
+func listGoals(repo GoalRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		goals, err := repo.ListGoals()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, goals)
+	}
+}
+
+// PUT /api/v1/goals/:exercise {"target_1rm": 180} creates or replaces the goal
+func putGoal(repo GoalRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input Goal
+		if err := c.ShouldBind(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if input.TargetOneRM <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_1rm must be positive"})
+			return
+		}
+		exercise := strings.TrimSpace(c.Param("exercise"))
+		if exercise == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exercise must not be blank"})
+			return
+		}
+
+		goal, existed := findGoal(repo, exercise)
+		input.ID, input.CreatedAt = goal.ID, goal.CreatedAt
+		input.Exercise = exercise
+		if err := repo.SaveGoal(&input); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		status := http.StatusOK
+		if !existed {
+			status = http.StatusCreated
+		}
+		c.JSON(status, input)
+	}
+}
+
+// DELETE /api/v1/goals/:exercise
+func deleteGoal(repo GoalRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deleted, err := repo.DeleteGoal(c.Param("exercise"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no goal for " + c.Param("exercise")})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// groupExercises lists the exercises whose config puts them in the
+// variation group, matched case-insensitively
+func groupExercises(configs ExerciseConfigRepository, group string) ([]string, error) {
+	all, err := configs.ListExerciseConfigs()
+	if err != nil {
+		return nil, err
+	}
+	var members []string
+	for _, cfg := range all {
+		if cfg.VariationGroup != "" && strings.EqualFold(cfg.VariationGroup, group) {
+			members = append(members, cfg.Exercise)
+		}
+	}
+	return members, nil
+}
+
+// GET /api/v1/groups/:group/progress
+// Combined progression for every exercise in a variation group, e.g.
+// Low-Bar and High-Bar Squat as "Squat": one session per training day,
+// PRs counted against the whole group, plus each member's own best.
+func getGroupProgress(repo WorkoutRepository, configs ExerciseConfigRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group := strings.TrimSpace(c.Param("group"))
+		members, err := groupExercises(configs, group)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(members) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no exercises in variation group " + group})
+			return
+		}
+
+		var sets []Workout
+		for _, exercise := range members {
+			found, err := repo.ListWorkouts(exerciseQuery(exercise))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			sets = append(sets, found...)
+		}
+		sort.SliceStable(sets, func(i, j int) bool { return sets[i].CreatedAt.Before(sets[j].CreatedAt) })
+
+		type groupSession struct {
+			Date      string   `json:"date"`
+			Exercises []string `json:"exercises"`
+			Sets      int      `json:"sets"`
+			Volume    float64  `json:"volume"`
+			BestOneRM Weight   `json:"best_1rm"`
+		}
+		sessions := []groupSession{}
+		var best *Workout
+		for i, w := range sets {
+			day := dayKey(w.CreatedAt)
+			if n := len(sessions); n == 0 || sessions[n-1].Date != day {
+				sessions = append(sessions, groupSession{Date: day})
+			}
+			s := &sessions[len(sessions)-1]
+			if !slices.Contains(s.Exercises, w.Exercise) {
+				s.Exercises = append(s.Exercises, w.Exercise)
+			}
+			s.Sets++
+			s.Volume += config.workoutVolume(w)
+			e1rm := workoutOneRM(w)
+			s.BestOneRM = max(s.BestOneRM, Weight(e1rm))
+			if best == nil || e1rm > workoutOneRM(*best) {
+				best = &sets[i]
+			}
+		}
+
+		type memberBest struct {
+			Exercise  string  `json:"exercise"`
+			Sessions  int     `json:"sessions"`
+			BestOneRM *Weight `json:"best_1rm"` // nil until the exercise is logged
+		}
+		perExercise := make([]memberBest, 0, len(members))
+		// Days per member, counting every variation of it
+		days := map[string]map[string]bool{}
+		for _, w := range sets {
+			if days[w.Exercise] == nil {
+				days[w.Exercise] = map[string]bool{}
+			}
+			days[w.Exercise][dayKey(w.CreatedAt)] = true
+		}
+		for _, exercise := range members {
+			m := memberBest{Exercise: exercise, Sessions: len(days[exercise])}
+			if stat, ok := findExerciseStat(repo, exercise); ok {
+				b := Weight(stat.BestOneRM)
+				m.BestOneRM = &b
+			}
+			perExercise = append(perExercise, m)
+		}
+
+		resp := gin.H{
+			"group":        group,
+			"exercises":    members,
+			"sessions":     sessions,
+			"prs":          len(prIDs(sets, 0)),
+			"per_exercise": perExercise,
+			"best":         nil,
+		}
+		if best != nil {
+			resp["best"] = gin.H{
+				"exercise": best.Exercise,
+				"weight":   best.Weight,
+				"reps":     best.Reps,
+				"best_1rm": Weight(workoutOneRM(*best)),
+				"date":     dayKey(best.CreatedAt),
+			}
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
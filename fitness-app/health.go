@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Overridden at build time: go build -ldflags "-X main.version=1.2.3"
+var version = "dev"
+
+// Process start, set at the top of main
+var startTime time.Time
+
+func uptimeSeconds() int64 {
+	return int64(time.Since(startTime).Seconds())
+}
+
+// GET /health[?verbose=true]
+// The default stays terse for simple uptime monitors. Verbose adds DB latency,
+// uptime and version, but never connection details.
+func healthCheck(repo AdminRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if verbose, _ := parseFlexBool(c.Query("verbose")); !verbose {
+			c.JSON(http.StatusOK, gin.H{"status": "database connected & lifting"})
+			return
+		}
+
+		db := gin.H{"ok": false}
+		status, label := http.StatusServiceUnavailable, "degraded"
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+		start := time.Now()
+		if err := repo.Ping(ctx); err == nil {
+			db = gin.H{"ok": true, "latency_ms": float64(time.Since(start).Microseconds()) / 1000}
+			status, label = http.StatusOK, "ok"
+		}
+
+		c.JSON(status, gin.H{
+			"status":         label,
+			"database":       db,
+			"uptime_seconds": uptimeSeconds(),
+			"version":        version,
+		})
+	}
+}
+
+// GET /version
+func versionInfo(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"version":        version,
+		"started_at":     startTime,
+		"uptime_seconds": uptimeSeconds(),
+	})
+}
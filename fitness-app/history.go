@@ -0,0 +1,110 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// ExerciseArchive summarises the sets the history cap has pruned for one
+// exercise, so PRs, bests and lifetime totals still count them.
+type ExerciseArchive struct {
+	Exercise   string    `gorm:"primaryKey" json:"exercise"`
+	Sets       int64     `json:"sets"`
+	Volume     float64   `json:"volume"`
+	FirstLog   time.Time `json:"first_log"`
+	BestOneRM  float64   `json:"best_1rm"`
+	BestWeight Weight    `json:"best_weight"`
+	BestReps   int       `json:"best_reps"`
+	// Days and weeks that pruning this exercise left with no stored sets
+	// at all; summed across exercises they are the sessions and active
+	// weeks no longer visible in workouts.
+	EmptiedDays  int64     `json:"emptied_days"`
+	EmptiedWeeks int64     `json:"emptied_weeks"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// prunedSets returns the sets (oldest first) from all but the newest keep
+// days the exercise was trained. The Postgres repository picks the same
+// sets in SQL, with days in the same timezone, so it doesn't load the whole history on every write.
+func prunedSets(sets []Workout, keep int) []Workout {
+	days := 0
+	for i := len(sets) - 1; i >= 0; i-- {
+		if i == len(sets)-1 || dayKey(sets[i].CreatedAt) != dayKey(sets[i+1].CreatedAt) {
+			days++
+		}
+		if days > keep {
+			return sets[:i+1]
+		}
+	}
+	return nil
+}
+
+// add folds pruned sets into the archive
+func (a *ExerciseArchive) add(pruned []Workout, config Config) {
+	for _, w := range pruned {
+		a.Sets++
+		a.Volume += config.workoutVolume(w)
+		if a.FirstLog.IsZero() || w.CreatedAt.Before(a.FirstLog) {
+			a.FirstLog = w.CreatedAt
+		}
+		if e1rm := workoutOneRM(w); countsForPR(w) && e1rm > a.BestOneRM {
+			a.BestOneRM, a.BestWeight, a.BestReps = e1rm, w.Weight, w.Reps
+		}
+	}
+}
+
+// stat is the archived best as an ExerciseStat. It has no BestWorkoutID
+// since the set itself is gone.
+func (a ExerciseArchive) stat() ExerciseStat {
+	return ExerciseStat{Exercise: a.Exercise, BestOneRM: a.BestOneRM, BestWeight: a.BestWeight, BestReps: a.BestReps}
+}
+
+// prunedPeriods returns the local start of each day and week the pruned
+// sets fall in, for counting the ones left empty.
+func prunedPeriods(pruned []Workout) (days, weeks []time.Time) {
+	seenDay, seenWeek := map[time.Time]bool{}, map[time.Time]bool{}
+	for _, w := range pruned {
+		t := w.CreatedAt.In(time.Local)
+		y, m, d := t.Date()
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+		if !seenDay[day] {
+			seenDay[day] = true
+			days = append(days, day)
+		}
+		if week := startOfWeek(t); !seenWeek[week] {
+			seenWeek[week] = true
+			weeks = append(weeks, week)
+		}
+	}
+	return days, weeks
+}
+
+// addArchives counts pruned history into a summary of what's stored
+func (s *workoutSummary) addArchives(archives []ExerciseArchive) {
+	for _, a := range archives {
+		s.Sets += a.Sets
+		s.Volume += a.Volume
+		s.Sessions += a.EmptiedDays
+		s.ActiveWeeks += a.EmptiedWeeks
+		if a.Sets > 0 && (s.FirstLog == nil || a.FirstLog.Before(*s.FirstLog)) {
+			first := a.FirstLog
+			s.FirstLog = &first
+		}
+	}
+}
+
+// capHistory applies HISTORY_CAP_SESSIONS after a set is logged. It is a
+// side effect of writing, so failures are logged rather than returned.
+func capHistory(repo WorkoutRepository, exercise string, config Config) {
+	if !config.HistoryCapEnabled {
+		return
+	}
+	n, err := repo.PruneHistory(exercise, config.HistoryCapSessions)
+	if err != nil {
+		log.Printf("history cap: pruning %s: %v", exercise, err)
+		return
+	}
+	if n > 0 {
+		log.Printf("history cap: archived %d sets of %s", n, exercise)
+	}
+}
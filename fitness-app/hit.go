@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type failureCount struct {
+	Sets    int     `json:"sets"`
+	Failure int     `json:"failure_sets"`
+	Percent float64 `json:"failure_percent"`
+}
+
+func (f *failureCount) add(w Workout) {
+	f.Sets++
+	if w.IsFailure {
+		f.Failure++
+	}
+	f.Percent = 100 * float64(f.Failure) / float64(f.Sets)
+}
+
+// GET /api/v1/hit/stats (plus the usual window and set_type params)
+// How often sets are taken to failure, overall, per muscle group and per week.
+func getHITStats(repo WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, err := parseWindow(c, config.AnalyticsLookbackDays)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		types, err := config.setTypeFilters(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		workouts, err := repo.ListWorkouts(WorkoutQuery{Filters: types, From: from, To: to})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		overall := failureCount{}
+		byMuscle := map[string]*failureCount{}
+		type trendPoint struct {
+			Week string `json:"week"`
+			failureCount
+		}
+		trend := []*trendPoint{}
+
+		for _, w := range workouts {
+			overall.add(w)
+
+			group := w.MuscleGroup
+			if group == "" {
+				group = "Unassigned"
+			}
+			if byMuscle[group] == nil {
+				byMuscle[group] = &failureCount{}
+			}
+			byMuscle[group].add(w)
+
+			week := dayKey(startOfWeek(w.CreatedAt.In(time.Local)))
+			if n := len(trend); n == 0 || trend[n-1].Week != week {
+				trend = append(trend, &trendPoint{Week: week})
+			}
+			trend[len(trend)-1].add(w)
+		}
+
+		groups := make([]string, 0, len(byMuscle))
+		for g := range byMuscle {
+			groups = append(groups, g)
+		}
+		sort.Strings(groups)
+		muscles := make([]gin.H, 0, len(groups))
+		for _, g := range groups {
+			muscles = append(muscles, gin.H{"muscle_group": g, "stats": byMuscle[g]})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"overall":         overall,
+			"by_muscle_group": muscles,
+			"trend":           trend,
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Largest import file accepted, CSV or JSON
+const maxImportBytes = 32 << 20
+
+// importRow is one row's outcome. Row counts from 1, after any CSV header.
+type importRow struct {
+	Row     int      `json:"row"`
+	Valid   bool     `json:"valid"`
+	Error   string   `json:"error,omitempty"`
+	Workout *Workout `json:"workout,omitempty"`
+}
+
+type importReport struct {
+	Total   int         `json:"total"`
+	Valid   int         `json:"valid"`
+	Invalid int         `json:"invalid"`
+	Rows    []importRow `json:"rows"`
+}
+
+// readImport takes the file from a multipart "file" field or, for JSON,
+// the request body itself
+func readImport(c *gin.Context) ([]byte, error) {
+	var r io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("file: %w", err)
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxImportBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImportBytes {
+		return nil, fmt.Errorf("import is larger than %d MB", maxImportBytes>>20)
+	}
+	return data, nil
+}
+
+// parseImport reads a JSON array of workouts or a CSV in the export format
+// (see workoutCSVHeader; columns may be in any order, id and is_pr are
+// ignored) and validates every row the way POST /workout does.
+func parseImport(data []byte, now time.Time, config Config) (importReport, error) {
+	var rows []importRow
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return importReport{}, fmt.Errorf("import is empty")
+	}
+	if trimmed[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(trimmed, &raw); err != nil {
+			return importReport{}, fmt.Errorf("JSON import must be an array of workouts: %w", err)
+		}
+		for i, item := range raw {
+			var w Workout
+			err := json.Unmarshal(item, &w)
+			rows = append(rows, checkImportRow(i+1, w, err, now, config))
+		}
+	} else {
+		var err error
+		if rows, err = parseImportCSV(trimmed, now, config); err != nil {
+			return importReport{}, err
+		}
+	}
+
+	report := importReport{Total: len(rows), Rows: rows}
+	for _, r := range rows {
+		if r.Valid {
+			report.Valid++
+		} else {
+			report.Invalid++
+		}
+	}
+	return report, nil
+}
+
+func parseImportCSV(data []byte, now time.Time, config Config) ([]importRow, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV: %w", err)
+	}
+	col := map[string]int{}
+	for i, name := range records[0] {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"exercise", "reps", "weight"} {
+		if _, ok := col[required]; !ok {
+			return nil, fmt.Errorf("CSV header must include %s", required)
+		}
+	}
+
+	var rows []importRow
+	for i, record := range records[1:] {
+		field := func(name string) string {
+			if j, ok := col[name]; ok && j < len(record) {
+				return strings.TrimSpace(record[j])
+			}
+			return ""
+		}
+		w, err := workoutFromCSV(field)
+		rows = append(rows, checkImportRow(i+1, w, err, now, config))
+	}
+	return rows, nil
+}
+
+// workoutFromCSV reads one row; blank numeric fields are 0 and a blank
+// timestamp means now
+func workoutFromCSV(field func(string) string) (Workout, error) {
+	w := Workout{
+		Exercise:    field("exercise"),
+		Variation:   field("variation"),
+		Tempo:       field("tempo"),
+		MuscleGroup: field("muscle_group"),
+		Equipment:   field("equipment"),
+		SetType:     field("set_type"),
+		Tags:        parseTags(field("tags")),
+	}
+	ints := map[string]*int{"reps": &w.Reps, "forced_reps": &w.ForcedReps, "partial_reps": &w.PartialReps, "rpe": &w.RPE}
+	for name, dst := range ints {
+		if raw := field(name); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil {
+				return w, fmt.Errorf("%s must be an integer, got %q", name, raw)
+			}
+			*dst = v
+		}
+	}
+	if raw := field("weight"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return w, fmt.Errorf("weight must be a number, got %q", raw)
+		}
+		w.Weight = Weight(v)
+	}
+	bools := map[string]*FlexBool{"is_failure": &w.IsFailure, "per_side": &w.PerSide, "is_amrap": &w.IsAMRAP}
+	for name, dst := range bools {
+		if err := dst.UnmarshalParam(field(name)); err != nil {
+			return w, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if raw := field("timestamp"); raw != "" {
+		t, err := parseWindowTime(raw, false)
+		if err != nil {
+			return w, fmt.Errorf("timestamp: %w", err)
+		}
+		w.CreatedAt = t
+	}
+	return w, nil
+}
+
+// checkImportRow applies the same binding and validation rules as
+// POST /workout, plus a sanity check on the timestamp
+func checkImportRow(n int, w Workout, parseErr error, now time.Time, config Config) importRow {
+	row := importRow{Row: n}
+	err := parseErr
+	if err == nil {
+		err = binding.Validator.ValidateStruct(&w)
+	}
+	if err == nil {
+		err = validateWorkout(&w, config)
+	}
+	if err == nil && w.CreatedAt.After(now) {
+		err = fmt.Errorf("timestamp %s is in the future", w.CreatedAt.Format(time.RFC3339))
+	}
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	w.ID, w.IsPR, w.DropSetID = 0, false, 0
+	if w.CreatedAt.IsZero() {
+		w.CreatedAt = now
+	}
+	inferFailureRPE(&w, config)
+	row.Valid, row.Workout = true, &w
+	return row
+}
+
+func importFromRequest(c *gin.Context, config Config) (importReport, bool) {
+	data, err := readImport(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return importReport{}, false
+	}
+	report, err := parseImport(data, time.Now(), config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return importReport{}, false
+	}
+	return report, true
+}
+
+// POST /api/v1/workouts/import/preview
+// Parses and validates an import exactly like the real import, reporting
+// each row, without writing anything.
+func previewImport(config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, ok := importFromRequest(c, config)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// POST /api/v1/workouts/import
+// All or nothing: any invalid row rejects the whole file with the same
+// report as the preview. PR flags are recomputed for imported exercises.
+func importWorkouts(repo WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, ok := importFromRequest(c, config)
+		if !ok {
+			return
+		}
+		if report.Invalid > 0 || report.Total == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "nothing imported, fix the invalid rows first", "report": report})
+			return
+		}
+
+		workouts := make([]Workout, len(report.Rows))
+		for i, r := range report.Rows {
+			workouts[i] = *r.Workout
+		}
+		if err := repo.ImportWorkouts(workouts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"imported": len(workouts)})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Weekly working-set landmarks for one muscle group: minimum effective,
+// maximum adaptive and maximum recoverable volume.
+type landmark struct {
+	MEV int `json:"mev"`
+	MAV int `json:"mav"`
+	MRV int `json:"mrv"`
+}
+
+// Typical starting points; override with VOLUME_LANDMARKS
+var defaultLandmarks = map[string]landmark{
+	"back":       {10, 18, 25},
+	"biceps":     {8, 17, 26},
+	"calves":     {8, 14, 20},
+	"chest":      {8, 16, 22},
+	"glutes":     {0, 8, 16},
+	"hamstrings": {6, 13, 20},
+	"quads":      {8, 15, 20},
+	"shoulders":  {8, 19, 26},
+	"triceps":    {6, 12, 18},
+}
+
+// parseLandmarks reads "chest=10/16/22,back=10/18/25"; muscle groups not
+// listed keep their defaults.
+func parseLandmarks(raw string, fallback map[string]landmark) (map[string]landmark, error) {
+	out := make(map[string]landmark, len(fallback))
+	for k, v := range fallback {
+		out[k] = v
+	}
+	for _, item := range strings.Split(raw, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		var l landmark
+		name, counts, ok := strings.Cut(item, "=")
+		if ok {
+			_, err := fmt.Sscanf(strings.TrimSpace(counts), "%d/%d/%d", &l.MEV, &l.MAV, &l.MRV)
+			ok = err == nil && l.MEV >= 0 && l.MEV <= l.MAV && l.MAV <= l.MRV
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected muscle=MEV/MAV/MRV with MEV <= MAV <= MRV, got %q", item)
+		}
+		out[name] = l
+	}
+	return out, nil
+}
+
+type muscleStatus struct {
+	MuscleGroup string `json:"muscle_group"`
+	Sets        int    `json:"sets"`
+	Status      string `json:"status"`
+	AboveMAV    bool   `json:"above_mav"`
+	landmark
+}
+
+func landmarkStatus(sets int, l landmark) string {
+	switch {
+	case sets < l.MEV:
+		return "below MEV"
+	case sets > l.MRV:
+		return "above MRV"
+	}
+	return "in range"
+}
+
+// GET /api/v1/landmarks/status?week=2024-W23[&set_type=...|&exclude_set_type=...]
+// Where each muscle group's working sets for the week (default: this week)
+// sit against its landmarks.
+func getLandmarkStatus(repo WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start, err := parseISOWeek(c.Query("week"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		types, err := config.setTypeFilters(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		rollups, err := repo.ListWeeklySummaries(start, start.AddDate(0, 0, 7).Add(-time.Nanosecond))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		statuses, untracked := config.muscleStatuses(filterRollups(rollups, types))
+		year, week := start.ISOWeek()
+		c.JSON(http.StatusOK, gin.H{
+			"week":      fmt.Sprintf("%d-W%02d", year, week),
+			"muscles":   statuses,
+			"untracked": untracked,
+		})
+	}
+}
+
+// muscleStatuses counts a week's working sets per muscle group, from its
+// rollup rows, against the landmarks. Trained groups without landmarks come
+// back as untracked so nothing goes missing.
+func (c Config) muscleStatuses(rollups []WeeklySummary) ([]muscleStatus, []string) {
+	counts := map[string]int{}
+	for _, r := range rollups {
+		if r.WorkingSets > 0 && r.MuscleGroup != "" {
+			counts[r.MuscleGroup] += r.WorkingSets
+		}
+	}
+
+	groups := make([]string, 0, len(c.VolumeLandmarks))
+	for g := range c.VolumeLandmarks {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	statuses := make([]muscleStatus, 0, len(groups))
+	for _, g := range groups {
+		l := c.VolumeLandmarks[g]
+		statuses = append(statuses, muscleStatus{
+			MuscleGroup: g,
+			Sets:        counts[g],
+			Status:      landmarkStatus(counts[g], l),
+			AboveMAV:    counts[g] > l.MAV,
+			landmark:    l,
+		})
+	}
+
+	untracked := []string{}
+	for g := range counts {
+		if _, ok := c.VolumeLandmarks[g]; !ok {
+			untracked = append(untracked, g)
+		}
+	}
+	sort.Strings(untracked)
+	return statuses, untracked
+}
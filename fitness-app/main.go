@@ -2,9 +2,13 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
+	_ "time/tzdata" // Day boundaries follow TZ even without system zoneinfo
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/postgres"
@@ -14,14 +18,24 @@ import (
 type Workout struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	Exercise    string    `json:"exercise" form:"exercise" binding:"required"`
+	Variation   string    `gorm:"not null;default:''" json:"variation" form:"variation"` // e.g. "close grip", tracked apart from the plain exercise
 	Reps        int       `json:"reps" form:"reps" binding:"required"`
-	Weight      float64   `json:"weight" form:"weight" binding:"required"`
+	ForcedReps  int       `json:"forced_reps" form:"forced_reps"`   // Assisted reps past failure
+	PartialReps int       `json:"partial_reps" form:"partial_reps"` // Reduced range-of-motion reps
+	Weight      Weight    `json:"weight" form:"weight"`           // Required unless Equipment is "Bodyweight", see workoutStructLevel
 	RPE         int       `json:"rpe" form:"rpe"`                // 1-10 Intensity
 	Tempo       string    `json:"tempo" form:"tempo"`            // e.g., "3-0-1"
 	MuscleGroup string    `json:"muscle_group" form:"muscle_group"` // e.g., "Chest", "Back"
 	Equipment   string    `json:"equipment" form:"equipment"`    // "Dumbbell", "Machine"
-	IsFailure   bool      `json:"is_failure" form:"is_failure"`  // HIT Focus
+	IsFailure   FlexBool  `json:"is_failure" form:"is_failure"`  // HIT Focus
+	PerSide     FlexBool  `json:"per_side" form:"per_side"`      // Unilateral: weight and reps are for one side
+	IsAMRAP     FlexBool  `gorm:"not null;default:false" json:"is_amrap" form:"is_amrap"` // As many reps as possible: an all-out test set
+	SetType     string    `gorm:"not null;default:'working'" json:"set_type" form:"set_type"` // One of SET_TYPES, e.g. "warmup", "backoff"
+	IsPR        bool      `json:"is_pr" form:"-"`                // Set at insert, see recomputePRs
+	Tags        Tags      `json:"tags" form:"tags"`              // e.g. "compound,heavy"
+	DropSetID   uint      `gorm:"index" json:"drop_set_id,omitempty" form:"-"` // ID of the first set in a drop set
 	CreatedAt   time.Time `json:"timestamp"`
+	UpdatedAt   time.Time `json:"updated_at" form:"-"` // Bumped by retagging and muscle group syncs, not by PR recomputes
 }
 
 type BodyMetrics struct {
@@ -29,28 +43,88 @@ type BodyMetrics struct {
 	ShoulderCircumference float64   `json:"shoulder_circumference" form:"shoulder"`
 	WaistCircumference    float64   `json:"waist_circumference" form:"waist"`
 	ChestCircumference    float64   `json:"chest_circumference" form:"chest"`
+	// Optional extra sites; 0 means not measured this time
+	ArmCircumference     float64   `json:"arm_circumference" form:"arm"`
+	ThighCircumference   float64   `json:"thigh_circumference" form:"thigh"`
+	CalfCircumference    float64   `json:"calf_circumference" form:"calf"`
+	HipCircumference     float64   `json:"hip_circumference" form:"hip"`
+	NeckCircumference    float64   `json:"neck_circumference" form:"neck"`
+	Bodyweight           float64   `json:"bodyweight" form:"bodyweight"` // kg
+	Notes                string    `gorm:"not null;default:''" json:"notes" form:"notes"` // e.g. "fasted, morning"
 	CreatedAt            time.Time `json:"timestamp"`
+	UpdatedAt            time.Time `json:"updated_at" form:"-"`
 }
 
-var DB *gorm.DB
+// POST response: the saved workout plus any feedback about it
+type workoutResponse struct {
+	Workout
+	PR            *PRHighlight   `json:"pr,omitempty"`
+	NextSetAdvice *NextSetAdvice `json:"next_set_advice,omitempty"`
+	Warnings      []Warning      `json:"warnings,omitempty"`
+	Warning       string         `json:"warning,omitempty"` // Deprecated: the recovery message, until clients read Warnings
+	Drops         []Workout      `json:"drops,omitempty"`
+}
 
-func initDatabase() {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASS"),
-		os.Getenv("DB_NAME"), os.Getenv("DB_PORT"))
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+func validateDatabaseURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("DATABASE_URL is malformed: %w", err)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return fmt.Errorf("DATABASE_URL must use the postgres:// scheme, got %q", u.Scheme)
+	}
+	if u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return fmt.Errorf("DATABASE_URL must include a host and database name")
+	}
+	return nil
+}
+
+// databaseDSN prefers a single DATABASE_URL (as Heroku/Render/Railway provide)
+// and falls back to the discrete DB_* variables. loadConfig has already
+// validated the URL.
+func databaseDSN(c DBConfig) string {
+	if c.URL != "" {
+		return c.URL
+	}
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		c.Host, c.User, c.Password, c.Name, c.Port)
+}
+
+func initDatabase(config Config) *gorm.DB {
+	db, err := gorm.Open(postgres.Open(databaseDSN(config.DB)), &gorm.Config{CreateBatchSize: config.DB.BatchSize})
 	if err != nil {
 		panic("Failed to connect to database!")
 	}
+	db = withConfig(db, config)
 	// Migrate the schema
-	DB.AutoMigrate(&Workout{}, &BodyMetrics{})
+	if err := migrate(db, config); err != nil {
+		panic(err)
+	}
+	return db
 }
 
 func main() {
-	initDatabase()
+	startTime = time.Now()
+	config, err := loadConfig(os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("config: %+v", config.Redacted())
+
+	repo := openStore(config)
+	runSeeders(repo, config)
+	newRouter(repo, config).Run(":" + config.Port)
+}
+
+// newRouter wires every route to the given storage. It takes the whole
+// Repository so the app can be driven in-process (e.g. with httptest and
+// the memory store) without a database.
+func newRouter(repo Repository, config Config) *gin.Engine {
+	registerValidations()
 	r := gin.Default()
+	targets := newTargetCache(time.Duration(config.TargetDebounceMillis) * time.Millisecond)
+	r.Use(roundWeights(config.WeightPrecision), jsonNaming(config.JSONFieldNaming), requireBindableBody(pprofPath+"/"), targets.invalidateOnWrite())
 
 	// Load templates
 	r.LoadHTMLFiles("index.html")
@@ -62,117 +136,120 @@ func main() {
 	})
 
 	// Health check
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "database connected & lifting"})
-	})
+	r.GET("/health", healthCheck(repo))
+	r.GET("/version", versionInfo)
 
 	// Combined API/HTMX Workout Route
-	r.POST("/api/v1/workout", func(c *gin.Context) {
-		var workout Workout
-		
-		// .ShouldBind detects if it's JSON or Form data automatically!
-		if err := c.ShouldBind(&workout); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-
-		DB.Create(&workout)
-
-		// Check if request is from HTMX
-		if c.GetHeader("HX-Request") == "true" {
-			htmlSnippet := fmt.Sprintf(`
-				<div class="p-3 bg-slate-700 rounded border-l-4 border-green-500 shadow-sm animate-pulse">
-					<span class="font-bold text-blue-400">%s</span>: %d reps @ %.1fkg
-				</div>`, workout.Exercise, workout.Reps, workout.Weight)
-			c.Writer.Header().Set("Content-Type", "text/html")
-			c.String(http.StatusCreated, htmlSnippet)
-			return
-		}
-
-		// Otherwise, return JSON for standard API users
-		c.JSON(http.StatusCreated, workout)
-	})
+	r.POST("/api/v1/workout", createWorkout(repo, repo, config))
 
 	// Get All Workouts
-	r.GET("/api/v1/workouts", func(c *gin.Context) {
-		var workouts []Workout
-		DB.Order("created_at desc").Find(&workouts)
-		
-		// If HTMX is requesting the list (initial load)
-		if c.GetHeader("HX-Request") == "true" {
-			var html string
-			for _, w := range workouts {
-				// Simple HIT Intensity indicator
-				intensityBadge := ""
-				if w.IsFailure {
-					intensityBadge = "🔥 HIT"
-				}
-				html += fmt.Sprintf(`
-					<div class="p-3 bg-slate-700 rounded border-l-4 border-blue-500 mb-2">
-						<div class="flex justify-between items-center">
-							<span class="font-bold text-lg">%s</span>
-							<span class="text-xs font-bold text-red-500">%s</span>
-						</div>
-						<div class="text-sm text-slate-300">
-							%d reps @ %.1fkg (RPE: %d)
-						</div>
-					</div>`, w.Exercise, intensityBadge, w.Reps, w.Weight, w.RPE)
-			}
-			c.Writer.Header().Set("Content-Type", "text/html")
-			c.String(http.StatusOK, html)
-			return
-		}
-		c.JSON(http.StatusOK, workouts)
-	})
+	r.GET("/api/v1/workouts", listWorkouts(repo, repo, config))
+	r.POST("/api/v1/workouts/import", importWorkouts(repo, config))
+	r.POST("/api/v1/workouts/import/preview", previewImport(config))
 
 	// Get Target for Exercise (Progressive Overload Logic)
-	r.GET("/api/v1/target", func(c *gin.Context) {
-		exercise := c.Query("exercise")
-		var lastWorkout Workout
-		
-		// Find last log for this exercise
-		if result := DB.Where("exercise = ?", exercise).Order("created_at desc").First(&lastWorkout); result.Error != nil {
-			c.JSON(http.StatusOK, gin.H{"weight": 0, "reps": 0, "message": "New Exercise"})
-			return
-		}
-
-		// Progressive Overload Algorithm (Simple HIT)
-		targetWeight := lastWorkout.Weight
-		targetReps := lastWorkout.Reps
-
-		// If last set was failure and reps > 8, increase weight by 2.5kg
-		if lastWorkout.IsFailure && lastWorkout.Reps >= 8 {
-			targetWeight += 2.5
-		} else {
-			// Otherwise try to add 1 rep
-			targetReps += 1
-		}
-
-		c.JSON(http.StatusOK, gin.H{
-			"weight": targetWeight,
-			"reps": targetReps,
-			"message": fmt.Sprintf("Last: %.1fkg x %d", lastWorkout.Weight, lastWorkout.Reps),
-		})
-	})
+	r.GET("/api/v1/target", getTarget(repo, repo, repo, targets, config))
+
+	// Last set for an exercise, for a one-tap "same as last time" re-log
+	r.GET("/api/v1/last", getLastWorkout(repo, repo))
+
+	// Estimated 1RM across formulas for the best set
+	r.GET("/api/v1/onerm/compare", compareOneRM(repo, repo))
+	r.GET("/api/v1/repmax", getRepMaxTable(repo, repo, config))
+	r.GET("/api/v1/prs/card", getPRCard(repo, repo))
+	r.GET("/api/v1/rep-prs", getRepPRs(repo, repo, config))
+
+	// Analytics
+	r.GET("/api/v1/acwr", getACWR(repo, config))
+	r.GET("/api/v1/overreaching", getOverreaching(repo, config))
+	r.GET("/api/v1/tut", getTUT(repo, repo, config))
+	r.GET("/api/v1/widget", getWidget(repo, repo, repo, config))
+	r.GET("/api/v1/experience", getExperience(repo))
+	r.GET("/api/v1/milestones", getMilestones(repo))
+	r.GET("/api/v1/stalled", getStalled(repo, config))
+	r.GET("/api/v1/sparkline", getSparkline(repo, repo))
+	r.GET("/api/v1/compare-exercises", compareExercises(repo, repo))
+	r.GET("/api/v1/groups/:group/progress", getGroupProgress(repo, repo, config))
+	r.GET("/api/v1/weekly-exercise-sets", getWeeklyExerciseSets(repo, config))
+	r.GET("/api/v1/weekly-summaries", listWeeklySummaries(repo, config))
+	r.GET("/api/v1/hit/stats", getHITStats(repo, config))
+	r.GET("/api/v1/session/estimate", estimateSession(repo))
+	r.GET("/api/v1/session/:date/order-analysis", getOrderAnalysis(repo))
+	r.GET("/api/v1/landmarks/status", getLandmarkStatus(repo, config))
+	r.GET("/api/v1/recovery/score", getRecoveryScore(repo, config))
+	r.GET("/api/v1/week/review", getWeekReview(repo, repo, repo, repo, config))
+	r.GET("/api/v1/week/:week/review", getWeekReview(repo, repo, repo, repo, config))
+
+	// Program
+	r.POST("/api/v1/program/training-max", setTrainingMax(repo))
+	r.POST("/api/v1/program/advance", advanceTrainingMax(repo, repo, config))
+
+	// Tags
+	r.POST("/api/v1/tags/apply", applyTags(repo, config))
+
+	// Exercise configs
+	r.GET("/api/v1/exercise-configs", listExerciseConfigs(repo))
+	r.GET("/api/v1/exercise-configs/:exercise", getExerciseConfig(repo))
+	r.PUT("/api/v1/exercise-configs/:exercise", putExerciseConfig(repo))
+	r.DELETE("/api/v1/exercise-configs/:exercise", deleteExerciseConfig(repo))
+	r.GET("/api/v1/exercises/suggestions", getExerciseSuggestions(repo, repo, config.ExerciseSeedFile))
+
+	// Favorites
+	r.GET("/api/v1/favorites", listFavorites(repo))
+	r.PUT("/api/v1/favorites/:exercise", setFavorite(repo, true))
+	r.DELETE("/api/v1/favorites/:exercise", setFavorite(repo, false))
+	r.POST("/api/v1/onboarding", onboard(repo, config))
+
+	r.GET("/api/v1/goals", listGoals(repo))
+	r.PUT("/api/v1/goals/:exercise", putGoal(repo))
+	r.DELETE("/api/v1/goals/:exercise", deleteGoal(repo))
+
+	// Training days, auto-named by the muscle groups they hit
+	r.GET("/api/v1/sessions", listSessions(repo, repo, repo, config))
+	r.PUT("/api/v1/sessions/:date/name", putSessionName(repo))
+	r.DELETE("/api/v1/sessions/:date/name", deleteSessionName(repo))
+
+	// Export
+	r.GET("/api/v1/export/workouts", exportWorkouts(repo, config))
+	r.GET("/api/v1/export/metrics", exportMetrics(repo, config))
+	r.GET("/api/v1/export/metadata", exportMetadata(repo, repo))
+
+	// Maintenance (admin only)
+	maintenance := r.Group("/api/v1/maintenance", requireAPIKey(config.APIKey))
+	maintenance.POST("/backfill-prs", backfillPRs(repo))
+	maintenance.POST("/rebuild-stats", rebuildStats(repo))
+	maintenance.POST("/rebuild-weekly-summaries", rebuildWeeklySummaries(repo))
+	maintenance.POST("/analyze", analyzeDatabase(repo))
+	maintenance.POST("/sync-muscle-groups", syncMuscleGroups(repo, repo))
+
+	// Profiling, behind the API key; only mounted with ENABLE_PPROF
+	if config.EnablePprof {
+		registerPprof(r.Group(pprofPath, requireAPIKey(config.APIKey)))
+	}
+
+	// Dev-only helpers; the routes don't exist outside development
+	if config.AppEnv == "development" {
+		r.POST("/api/v1/dev/reset", resetData(repo, config.ExerciseSeedFile))
+	}
 
 	// Log Body Metrics
-	r.POST("/api/v1/metrics", func(c *gin.Context) {
-		var metrics BodyMetrics
-		if err := c.ShouldBind(&metrics); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		metrics.CreatedAt = time.Now()
-		DB.Create(&metrics)
-		c.Status(http.StatusCreated)
-	})
+	r.POST("/api/v1/metrics", logMetrics(repo))
+	r.PUT("/api/v1/metrics/:id", putMetrics(repo))
 
 	// Get Body Metrics for Chart
-	r.GET("/api/v1/metrics", func(c *gin.Context) {
-		var metrics []BodyMetrics
-		DB.Order("created_at asc").Find(&metrics) // Ascending for charts
-		c.JSON(http.StatusOK, metrics)
-	})
-
-	r.Run(":8081")
+	r.GET("/api/v1/metrics", listMetrics(repo))
+	r.GET("/api/v1/metrics/reminder", metricsReminder(repo, config))
+	r.GET("/api/v1/metrics/gaps", getMetricsGaps(repo, config))
+	r.GET("/api/v1/metrics/ratio", getMetricsRatio(repo))
+	r.GET("/api/v1/metrics/correlation", getMetricsCorrelation(repo))
+	r.GET("/api/v1/metrics/site/:site/trend", getSiteTrend(repo))
+
+	// Cardio / conditioning
+	r.POST("/api/v1/cardio", logCardio(repo))
+	r.GET("/api/v1/cardio", listCardio(repo))
+	r.GET("/api/v1/cardio/:id", getCardio(repo))
+	r.PUT("/api/v1/cardio/:id", putCardio(repo))
+	r.DELETE("/api/v1/cardio/:id", deleteCardio(repo))
+
+	return r
 }
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// testApp is the full router over a fresh in-memory store, so handlers run
+// end to end without Postgres
+type testApp struct {
+	t      *testing.T
+	repo   *memoryRepository
+	config Config
+	router *gin.Engine
+}
+
+// Settings every test app starts from. Seeding skips the HTTP writes that
+// invalidate cached targets, so targets aren't cached at all.
+var testEnv = map[string]string{"DB_DRIVER": "memory", "TARGET_DEBOUNCE_MS": "0"}
+
+// newTestApp loads the config from env over testEnv; other keys take their
+// defaults
+func newTestApp(t *testing.T, env map[string]string) *testApp {
+	t.Helper()
+	config, err := loadConfig(func(key string) string {
+		if v, ok := env[key]; ok {
+			return v
+		}
+		return testEnv[key]
+	})
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	repo := newMemoryRepository(config)
+	return &testApp{t: t, repo: repo, config: config, router: newRouter(repo, config)}
+}
+
+// reset empties the store between cases
+func (a *testApp) reset() {
+	a.t.Helper()
+	if err := a.repo.Reset(context.Background()); err != nil {
+		a.t.Fatalf("reset: %v", err)
+	}
+}
+
+// seed stores sets as an import would, recomputing PR flags. Sets without a
+// CreatedAt are logged a minute apart, oldest first, ending now.
+func (a *testApp) seed(workouts ...Workout) []Workout {
+	a.t.Helper()
+	now := time.Now()
+	for i := range workouts {
+		if workouts[i].CreatedAt.IsZero() {
+			workouts[i].CreatedAt = now.Add(time.Duration(i-len(workouts)+1) * time.Minute)
+		}
+	}
+	if err := a.repo.ImportWorkouts(workouts); err != nil {
+		a.t.Fatalf("seed: %v", err)
+	}
+	return workouts
+}
+
+// do sends body as JSON (nil for none) and records the response. headers
+// are name/value pairs.
+func (a *testApp) do(method, path string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	a.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			a.t.Fatalf("encoding body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode unmarshals a JSON response into v
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+	}
+}
+
+func TestWorkoutEndpoints(t *testing.T) {
+	app := newTestApp(t, nil)
+	squat := func(weight Weight, reps int) Workout {
+		return Workout{Exercise: "Squat", Weight: weight, Reps: reps, MuscleGroup: "Quads"}
+	}
+
+	tests := []struct {
+		name    string
+		seed    []Workout
+		method  string
+		path    string
+		body    interface{}
+		headers []string
+		status  int
+		check   func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "create stores the set",
+			method: http.MethodPost, path: "/api/v1/workout",
+			body:   gin.H{"exercise": " Squat ", "weight": 100, "reps": 5},
+			status: http.StatusCreated,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var got workoutResponse
+				decode(t, rec, &got)
+				if got.ID == 0 || got.Exercise != "Squat" || got.Weight != 100 || got.SetType != "working" {
+					t.Errorf("got %+v", got.Workout)
+				}
+			},
+		},
+		{
+			name:   "create rejects a blank exercise",
+			method: http.MethodPost, path: "/api/v1/workout",
+			body:   gin.H{"exercise": "   ", "weight": 100, "reps": 5},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "create rejects zero reps",
+			method: http.MethodPost, path: "/api/v1/workout",
+			body:   gin.H{"exercise": "Squat", "weight": 100, "reps": 0},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "create over HTMX returns a card",
+			method: http.MethodPost, path: "/api/v1/workout",
+			body:    gin.H{"exercise": "Squat", "weight": 100, "reps": 5},
+			headers: []string{"HX-Request", "true"},
+			status:  http.StatusCreated,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				if ct := rec.Header().Get("Content-Type"); ct != "text/html" {
+					t.Errorf("Content-Type = %q", ct)
+				}
+			},
+		},
+		{
+			name:   "create over HTMX escapes the name",
+			method: http.MethodPost, path: "/api/v1/workout",
+			body:    gin.H{"exercise": "Squat", "variation": "<img src=x onerror=alert(1)>", "weight": 100, "reps": 5},
+			headers: []string{"HX-Request", "true"},
+			status:  http.StatusCreated,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				if body := rec.Body.String(); strings.Contains(body, "<img") {
+					t.Errorf("variation rendered unescaped: %s", body)
+				}
+			},
+		},
+		{
+			name:   "PR card over HTMX escapes the name",
+			seed:   []Workout{squat(80, 5)},
+			method: http.MethodPost, path: "/api/v1/workout",
+			body:    gin.H{"exercise": "Squat", "variation": "<img src=x onerror=alert(1)>", "weight": 100, "reps": 5},
+			headers: []string{"HX-Request", "true"},
+			status:  http.StatusCreated,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				if body := rec.Body.String(); !strings.Contains(body, "PR") || strings.Contains(body, "<img") {
+					t.Errorf("want an escaped PR card, got %s", body)
+				}
+			},
+		},
+		{
+			name:   "list returns every set",
+			seed:   []Workout{squat(100, 5), squat(105, 5), {Exercise: "Bench", Weight: 80, Reps: 8}},
+			method: http.MethodGet, path: "/api/v1/workouts",
+			status: http.StatusOK,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var got []Workout
+				decode(t, rec, &got)
+				if len(got) != 3 {
+					t.Errorf("got %d sets, want 3", len(got))
+				}
+			},
+		},
+		{
+			name:   "list filters by exercise",
+			seed:   []Workout{squat(100, 5), {Exercise: "Bench", Weight: 80, Reps: 8}},
+			method: http.MethodGet, path: "/api/v1/workouts?exercise=Bench",
+			status: http.StatusOK,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var got []Workout
+				decode(t, rec, &got)
+				if len(got) != 1 || got[0].Exercise != "Bench" {
+					t.Errorf("got %+v", got)
+				}
+			},
+		},
+		{
+			name:   "list is unbounded without a window",
+			seed:   []Workout{{Exercise: "Squat", Weight: 90, Reps: 5, CreatedAt: time.Now().AddDate(-2, 0, 0)}, squat(100, 5)},
+			method: http.MethodGet, path: "/api/v1/workouts",
+			status: http.StatusOK,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var got []Workout
+				decode(t, rec, &got)
+				if len(got) != 2 {
+					t.Errorf("got %d sets, want 2", len(got))
+				}
+			},
+		},
+		{
+			name:   "list honors an explicit window",
+			seed:   []Workout{{Exercise: "Squat", Weight: 90, Reps: 5, CreatedAt: time.Now().AddDate(-2, 0, 0)}, squat(100, 5)},
+			method: http.MethodGet, path: "/api/v1/workouts?days=30",
+			status: http.StatusOK,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var got []Workout
+				decode(t, rec, &got)
+				if len(got) != 1 || got[0].Weight != 100 {
+					t.Errorf("got %+v", got)
+				}
+			},
+		},
+		{
+			name:   "list ignores an empty set_type",
+			seed:   []Workout{squat(100, 5), {Exercise: "Squat", Weight: 60, Reps: 5, SetType: "warmup"}},
+			method: http.MethodGet, path: "/api/v1/workouts?set_type=",
+			status: http.StatusOK,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var got []Workout
+				decode(t, rec, &got)
+				if len(got) != 2 {
+					t.Errorf("got %d sets, want 2", len(got))
+				}
+			},
+		},
+		{
+			name:   "warm-ups carry no weekly volume",
+			seed:   []Workout{squat(100, 5), {Exercise: "Squat", Weight: 60, Reps: 5, SetType: "warmup"}},
+			method: http.MethodGet, path: "/api/v1/weekly-summaries?weeks=1",
+			status: http.StatusOK,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var got []weekSummary
+				decode(t, rec, &got)
+				if len(got) != 1 || got[0].Sets != 2 || got[0].WorkingSets != 1 || got[0].Volume != 500 {
+					t.Errorf("got %+v, want 2 sets, 1 working, 500kg", got)
+				}
+			},
+		},
+		{
+			name:   "warm-ups never hold a rep PR",
+			seed:   []Workout{squat(100, 5), {Exercise: "Squat", Weight: 120, Reps: 5, SetType: "warmup"}},
+			method: http.MethodGet, path: "/api/v1/rep-prs?exercise=Squat",
+			status: http.StatusOK,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var got struct {
+					PRs []repPR `json:"prs"`
+				}
+				decode(t, rec, &got)
+				if len(got.PRs) != 1 || got.PRs[0].Weight != 100 {
+					t.Errorf("got %+v, want the 100kg working set", got.PRs)
+				}
+			},
+		},
+		{
+			name:   "list rejects an unknown sort column",
+			method: http.MethodGet, path: "/api/v1/workouts?sort=password",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "target starts a new exercise at the defaults",
+			method: http.MethodGet, path: "/api/v1/target?exercise=Squat",
+			status: http.StatusOK,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var got struct {
+					Weight      float64 `json:"weight"`
+					Reps        int     `json:"reps"`
+					NewExercise bool    `json:"new_exercise"`
+				}
+				decode(t, rec, &got)
+				if !got.NewExercise || got.Weight != 20 || got.Reps != 8 {
+					t.Errorf("got %+v", got)
+				}
+			},
+		},
+		{
+			name:   "target adds weight after a hard set of 8+",
+			seed:   []Workout{{Exercise: "Squat", Weight: 100, Reps: 8, IsFailure: true}},
+			method: http.MethodGet, path: "/api/v1/target?exercise=Squat",
+			status: http.StatusOK,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var got struct {
+					Weight float64 `json:"weight"`
+					Reps   int     `json:"reps"`
+				}
+				decode(t, rec, &got)
+				if got.Weight != 102.5 || got.Reps != 8 {
+					t.Errorf("got %+v, want 102.5kg x 8", got)
+				}
+			},
+		},
+		{
+			name:   "target adds a rep after a set short of 8",
+			seed:   []Workout{squat(100, 5)},
+			method: http.MethodGet, path: "/api/v1/target?exercise=Squat",
+			status: http.StatusOK,
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var got struct {
+					Weight float64 `json:"weight"`
+					Reps   int     `json:"reps"`
+				}
+				decode(t, rec, &got)
+				if got.Weight != 100 || got.Reps != 6 {
+					t.Errorf("got %+v, want 100kg x 6", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app.t = t
+			app.reset()
+			app.seed(tt.seed...)
+			rec := app.do(tt.method, tt.path, tt.body, tt.headers...)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.check != nil {
+				tt.check(t, rec)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/v1/metrics/reminder
+func metricsReminder(repo MetricsRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		last, err := repo.LastMetrics()
+		if err != nil {
+			// Never measured: nudge straight away
+			c.JSON(http.StatusOK, gin.H{
+				"last_logged":  nil,
+				"days_since":   nil,
+				"due":          true,
+				"cadence_days": config.MetricsReminderDays,
+			})
+			return
+		}
+
+		daysSince := int(time.Since(last.CreatedAt).Hours() / 24)
+		c.JSON(http.StatusOK, gin.H{
+			"last_logged":  last.CreatedAt,
+			"days_since":   daysSince,
+			"due":          daysSince >= config.MetricsReminderDays,
+			"cadence_days": config.MetricsReminderDays,
+		})
+	}
+}
+
+// Circumference sites by the name the ratio and gap endpoints take
+var measurementSites = map[string]func(BodyMetrics) float64{
+	"shoulder": func(m BodyMetrics) float64 { return m.ShoulderCircumference },
+	"waist":    func(m BodyMetrics) float64 { return m.WaistCircumference },
+	"chest":    func(m BodyMetrics) float64 { return m.ChestCircumference },
+	"arm":      func(m BodyMetrics) float64 { return m.ArmCircumference },
+	"thigh":    func(m BodyMetrics) float64 { return m.ThighCircumference },
+	"calf":     func(m BodyMetrics) float64 { return m.CalfCircumference },
+	"hip":      func(m BodyMetrics) float64 { return m.HipCircumference },
+	"neck":     func(m BodyMetrics) float64 { return m.NeckCircumference },
+}
+
+func siteNames() string {
+	names := make([]string, 0, len(measurementSites))
+	for name := range measurementSites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func measurementSite(name string) (func(BodyMetrics) float64, error) {
+	site, ok := measurementSites[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown site %q, expected one of %s", name, siteNames())
+	}
+	return site, nil
+}
+
+// POST /api/v1/metrics
+// Any subset of sites (and bodyweight) may be logged; the rest stay 0.
+// Notes are optional free text on the conditions.
+func logMetrics(repo MetricsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		metrics, ok := bindMetrics(c)
+		if !ok {
+			return
+		}
+		metrics.ID, metrics.CreatedAt = 0, time.Now()
+		repo.CreateMetrics(&metrics)
+		c.Status(http.StatusCreated)
+	}
+}
+
+func validateMetrics(m *BodyMetrics) error {
+	m.Notes = strings.TrimSpace(m.Notes)
+	if m.Bodyweight < 0 {
+		return fmt.Errorf("bodyweight must not be negative")
+	}
+	measured := m.Bodyweight > 0
+	for name, site := range measurementSites {
+		if v := site(*m); v < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		} else if v > 0 {
+			measured = true
+		}
+	}
+	if !measured {
+		return fmt.Errorf("log at least one positive measurement or bodyweight")
+	}
+	return nil
+}
+
+// bindMetrics reads and validates a metrics entry from the request body
+func bindMetrics(c *gin.Context) (BodyMetrics, bool) {
+	var m BodyMetrics
+	if err := c.ShouldBind(&m); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return m, false
+	}
+	if err := validateMetrics(&m); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return m, false
+	}
+	return m, true
+}
+
+// PUT /api/v1/metrics/:id replaces an entry, keeping its timestamp, e.g. to
+// add a note after the fact
+func putMetrics(repo MetricsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+			return
+		}
+		existing, err := repo.GetMetrics(uint(id))
+		if err == errNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no metrics entry " + c.Param("id")})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		metrics, ok := bindMetrics(c)
+		if !ok {
+			return
+		}
+		metrics.ID, metrics.CreatedAt = existing.ID, existing.CreatedAt
+		if err := repo.SaveMetrics(&metrics); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, metrics)
+	}
+}
+
+// GET /api/v1/metrics, oldest first for charts
+func listMetrics(repo MetricsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		metrics, _ := repo.ListMetrics()
+		c.JSON(http.StatusOK, metrics)
+	}
+}
+
+type metricsRatio struct {
+	Timestamp time.Time `json:"timestamp"`
+	Ratio     float64   `json:"ratio"`
+}
+
+// GET /api/v1/metrics/ratio?a=shoulder&b=waist
+// a/b over time, oldest first, from the entries that measured both sites.
+// Defaults to the shoulder-to-waist V-taper.
+func getMetricsRatio(repo MetricsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		a, err := measurementSite(c.DefaultQuery("a", "shoulder"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a: " + err.Error()})
+			return
+		}
+		b, err := measurementSite(c.DefaultQuery("b", "waist"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "b: " + err.Error()})
+			return
+		}
+		metrics, err := repo.ListMetrics()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ratios := []metricsRatio{}
+		for _, m := range metrics {
+			if a(m) > 0 && b(m) > 0 {
+				ratios = append(ratios, metricsRatio{m.CreatedAt, math.Round(a(m)/b(m)*100) / 100})
+			}
+		}
+		c.JSON(http.StatusOK, ratios)
+	}
+}
+
+type sitePoint struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Value         float64   `json:"value"`
+	MovingAverage float64   `json:"moving_average"` // Of this and up to window-1 earlier points
+	Delta         float64   `json:"delta"`          // Change since the previous point
+}
+
+// siteTrend is the series of one site's measurements, oldest first,
+// skipping entries that didn't measure it
+func siteTrend(metrics []BodyMetrics, site func(BodyMetrics) float64, window int) []sitePoint {
+	points := []sitePoint{}
+	for _, m := range metrics {
+		v := site(m)
+		if v <= 0 {
+			continue
+		}
+		p := sitePoint{Timestamp: m.CreatedAt, Value: v}
+		if n := len(points); n > 0 {
+			p.Delta = math.Round((v-points[n-1].Value)*10) / 10
+		}
+		sum, count := v, 1
+		for i := len(points) - 1; i >= 0 && count < window; i-- {
+			sum += points[i].Value
+			count++
+		}
+		p.MovingAverage = math.Round(sum/float64(count)*10) / 10
+		points = append(points, p)
+	}
+	return points
+}
+
+// GET /api/v1/metrics/site/:site/trend[?window=3]
+// One measurement site over time with a moving average over the last
+// window entries. change is last minus first.
+func getSiteTrend(repo MetricsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		site, err := measurementSite(c.Param("site"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		window, err := strconv.Atoi(c.DefaultQuery("window", "3"))
+		if err != nil || window <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "window must be a positive integer"})
+			return
+		}
+		metrics, err := repo.ListMetrics()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		points := siteTrend(metrics, site, window)
+		resp := gin.H{
+			"site":   strings.ToLower(c.Param("site")),
+			"window": window,
+			"points": points,
+			"change": 0.0,
+		}
+		if n := len(points); n > 1 {
+			resp["change"] = math.Round((points[n-1].Value-points[0].Value)*10) / 10
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// Fewest paired entries a correlation is reported from
+const minCorrelationSamples = 3
+
+// pearson is the correlation coefficient of xs and ys, false when either
+// never varies
+func pearson(xs, ys []float64) (float64, bool) {
+	n := float64(len(xs))
+	var sx, sy float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+	}
+	mx, my := sx/n, sy/n
+	var cov, vx, vy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(vx*vy), true
+}
+
+// GET /api/v1/metrics/correlation?site=waist
+// Pearson correlation between a site and bodyweight over the entries that
+// logged both. With too few of them, or no variation, coefficient is null
+// and message says why.
+func getMetricsCorrelation(repo MetricsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := strings.ToLower(c.DefaultQuery("site", "waist"))
+		site, err := measurementSite(name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		metrics, err := repo.ListMetrics()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var xs, ys []float64
+		for _, m := range metrics {
+			if v := site(m); v > 0 && m.Bodyweight > 0 {
+				xs, ys = append(xs, v), append(ys, m.Bodyweight)
+			}
+		}
+		resp := gin.H{"site": name, "samples": len(xs), "coefficient": nil}
+		if len(xs) < minCorrelationSamples {
+			resp["message"] = fmt.Sprintf("Need at least %d entries with both %s and bodyweight, have %d", minCorrelationSamples, name, len(xs))
+			c.JSON(http.StatusOK, resp)
+			return
+		}
+		r, ok := pearson(xs, ys)
+		if !ok {
+			resp["message"] = fmt.Sprintf("%s or bodyweight never changed, so there is nothing to correlate", name)
+			c.JSON(http.StatusOK, resp)
+			return
+		}
+		resp["coefficient"] = math.Round(r*1000) / 1000
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+var metricsCadences = map[string]int{"daily": 1, "weekly": 7, "biweekly": 14, "monthly": 30}
+
+// calendarDays counts local calendar days from a to b, ignoring DST shifts
+func calendarDays(a, b time.Time) int {
+	ay, am, ad := a.In(time.Local).Date()
+	by, bm, bd := b.In(time.Local).Date()
+	from := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	to := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+type metricsGap struct {
+	From    string `json:"from"` // First day without a measurement
+	To      string `json:"to"`   // Last day without one
+	Days    int    `json:"days"`
+	Ongoing bool   `json:"ongoing"` // Still open today
+}
+
+// metricsGaps finds the stretches between measurements (oldest first) that
+// ran longer than the cadence, including the one up to today.
+func metricsGaps(metrics []BodyMetrics, cadence int, now time.Time) []metricsGap {
+	gaps := []metricsGap{}
+	add := func(prev, next time.Time, ongoing bool) {
+		// Days strictly between the two; an open gap only counts once a
+		// whole cadence has passed before today
+		empty := calendarDays(prev, next) - 1
+		if empty < cadence {
+			return
+		}
+		gap := metricsGap{From: dayKey(prev.AddDate(0, 0, 1)), To: dayKey(next.AddDate(0, 0, -1)), Days: empty}
+		if ongoing {
+			gap.To, gap.Days, gap.Ongoing = dayKey(next), empty+1, true
+		}
+		gaps = append(gaps, gap)
+	}
+	for i := 1; i < len(metrics); i++ {
+		add(metrics[i-1].CreatedAt, metrics[i].CreatedAt, false)
+	}
+	if len(metrics) > 0 {
+		add(metrics[len(metrics)-1].CreatedAt, now, true)
+	}
+	return gaps
+}
+
+// GET /api/v1/metrics/gaps?expected=daily|weekly|biweekly|monthly|<days>[&site=arm]
+// Stretches of at least the expected cadence without a measurement, in TZ
+// calendar days. Defaults to METRICS_REMINDER_DAYS. With site, only entries
+// that measured it count.
+func getMetricsGaps(repo MetricsRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cadence := config.MetricsReminderDays
+		if raw := c.Query("expected"); raw != "" {
+			days, ok := metricsCadences[strings.ToLower(raw)]
+			if !ok {
+				n, err := strconv.Atoi(raw)
+				if err != nil || n <= 0 {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "expected must be daily, weekly, biweekly, monthly or a number of days"})
+					return
+				}
+				days = n
+			}
+			cadence = days
+		}
+
+		var site func(BodyMetrics) float64
+		if raw := c.Query("site"); raw != "" {
+			var err error
+			if site, err = measurementSite(raw); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		metrics, err := repo.ListMetrics()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if site != nil {
+			measured := metrics[:0]
+			for _, m := range metrics {
+				if site(m) > 0 {
+					measured = append(measured, m)
+				}
+			}
+			metrics = measured
+		}
+		gaps := metricsGaps(metrics, cadence, time.Now())
+		missing := 0
+		for _, g := range gaps {
+			missing += g.Days
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"cadence_days": cadence,
+			"gaps":         gaps,
+			"missing_days": missing,
+		})
+	}
+}
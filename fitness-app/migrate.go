@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// Arbitrary app-wide key for pg_advisory_lock
+const migrationLockKey = 720341
+
+// Models managed by AutoMigrate
+var models = []interface{}{&Workout{}, &BodyMetrics{}, &ExerciseConfig{}, &ExerciseStat{}, &TrainingMax{}, &Goal{}, &Profile{}, &ExerciseArchive{}, &Cardio{}, &SessionName{}, &WeeklySummary{}, &WeeklyArchive{}}
+
+// migrate runs AutoMigrate under a Postgres advisory lock so that replicas
+// starting together don't race each other. The first to get the lock
+// migrates; the rest wait for it and then run the migration too, which is
+// a no-op if it succeeded and a retry if it didn't.
+func migrate(db *gorm.DB, config Config) error {
+	instance, _ := os.Hostname()
+
+	return db.Connection(func(conn *gorm.DB) error {
+		var acquired bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", migrationLockKey).Scan(&acquired).Error; err != nil {
+			return fmt.Errorf("acquiring migration lock: %w", err)
+		}
+
+		if !acquired {
+			log.Printf("migration: %s waiting for another instance to finish", instance)
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+				return fmt.Errorf("waiting for migration lock: %w", err)
+			}
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey)
+
+		// Weekly rollups were once keyed by week alone; the table is derived,
+		// so it's dropped and backfilled rather than altered
+		if conn.Migrator().HasTable(&WeeklySummary{}) && !conn.Migrator().HasColumn(&WeeklySummary{}, "exercise") {
+			if err := conn.Migrator().DropTable(&WeeklySummary{}); err != nil {
+				return fmt.Errorf("dropping old weekly summaries: %w", err)
+			}
+		}
+		if err := conn.AutoMigrate(models...); err != nil {
+			return fmt.Errorf("auto-migrate: %w", err)
+		}
+		if err := backfillWeeklySummaries(conn, config); err != nil {
+			return fmt.Errorf("backfilling weekly summaries: %w", err)
+		}
+		if err := backfillExerciseStats(conn); err != nil {
+			return fmt.Errorf("backfilling exercise stats: %w", err)
+		}
+		log.Printf("migration: performed by %s", instance)
+		return nil
+	})
+}
+
+// backfillWeeklySummaries fills an empty weekly_summaries from existing sets,
+// so a new or reshaped table doesn't start the charts from zero
+func backfillWeeklySummaries(db *gorm.DB, config Config) error {
+	var rows, sets int64
+	if err := db.Model(&WeeklySummary{}).Count(&rows).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&Workout{}).Count(&sets).Error; err != nil {
+		return err
+	}
+	if rows > 0 || sets == 0 {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		rebuilt, _, err := rebuildRollups(tx, config)
+		if err == nil {
+			log.Printf("migration: backfilled %d weeks of weekly summaries", countWeeks(rebuilt))
+		}
+		return err
+	})
+}
+
+// backfillExerciseStats builds the stat row of every exercise that has sets
+// but none, e.g. sets logged before exercise_stats existed, since all-time
+// bests read only the stats
+func backfillExerciseStats(db *gorm.DB) error {
+	var exercises []string
+	err := db.Model(&Workout{}).Distinct("exercise").
+		Where("set_type <> ? AND NOT EXISTS (SELECT 1 FROM exercise_stats s WHERE s.exercise = workouts.exercise)", warmupSetType).
+		Pluck("exercise", &exercises).Error
+	if err != nil || len(exercises) == 0 {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, exercise := range exercises {
+			if err := rebuildExerciseStat(tx, exercise); err != nil {
+				return err
+			}
+		}
+		log.Printf("migration: backfilled exercise stats for %d exercises", len(exercises))
+		return nil
+	})
+}
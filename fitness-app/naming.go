@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JSON responses are snake_case by default. With JSON_FIELD_NAMING=camel, or
+// per request with "Accept: application/json; naming=camel", keys are
+// rewritten to camelCase on the way out so every model and handler is
+// covered without touching struct tags.
+
+func wantsCamelCase(c *gin.Context, fallback string) bool {
+	accept := strings.ToLower(c.GetHeader("Accept"))
+	switch {
+	case strings.Contains(accept, "naming=camel"):
+		return true
+	case strings.Contains(accept, "naming=snake"):
+		return false
+	}
+	return fallback == "camel"
+}
+
+func snakeToCamel(s string) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func camelizeKeys(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			out[snakeToCamel(k)] = camelizeKeys(val)
+		}
+		return out
+	case []interface{}:
+		for i := range t {
+			t[i] = camelizeKeys(t[i])
+		}
+		return t
+	}
+	return v
+}
+
+// camelWriter buffers JSON bodies so their keys can be rewritten; anything
+// else passes straight through.
+type camelWriter struct {
+	gin.ResponseWriter
+	buf         bytes.Buffer
+	status      int
+	passthrough bool
+	decided     bool
+}
+
+func (w *camelWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *camelWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *camelWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.buf.Write(data)
+}
+
+func (w *camelWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *camelWriter) flush() {
+	if w.passthrough {
+		return
+	}
+	body := w.buf.Bytes()
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err == nil {
+		if out, err := json.Marshal(camelizeKeys(v)); err == nil {
+			body = out
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
+
+func jsonNaming(naming string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !wantsCamelCase(c, naming) {
+			c.Next()
+			return
+		}
+		w := &camelWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		if w.decided || w.buf.Len() > 0 {
+			w.flush()
+		} else if w.status != http.StatusOK {
+			w.ResponseWriter.WriteHeader(w.status)
+		}
+	}
+}
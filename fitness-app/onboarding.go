@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const kgPerLb = 0.45359237
+
+type onboardingRequest struct {
+	Unit       string  `json:"unit"`       // "kg" (default) or "lbs"; applies to every weight below
+	Bodyweight float64 `json:"bodyweight"` // Optional
+	Lifts      []struct {
+		Exercise string  `json:"exercise"`
+		OneRM    float64 `json:"one_rm"`
+	} `json:"lifts"`
+	Goals []struct {
+		Exercise    string  `json:"exercise"`
+		TargetOneRM float64 `json:"target_1rm"`
+	} `json:"goals"`
+}
+
+// onboarding is everything a first run creates, written in one transaction
+type onboarding struct {
+	Profile         Profile          `json:"profile"`
+	Metrics         *BodyMetrics     `json:"metrics,omitempty"`
+	ExerciseConfigs []ExerciseConfig `json:"exercise_configs"`
+	TrainingMaxes   []TrainingMax    `json:"training_maxes"`
+	Goals           []Goal           `json:"goals"`
+}
+
+// plan validates the whole request at once, reporting every problem, and
+// turns it into the records to create (in kg).
+func (req onboardingRequest) plan(config Config) (*onboarding, []string) {
+	var errs []string
+	unit := strings.ToLower(strings.TrimSpace(req.Unit))
+	toKg := 1.0
+	switch unit {
+	case "", "kg":
+		unit = "kg"
+	case "lbs", "lb":
+		unit, toKg = "lbs", kgPerLb
+	default:
+		errs = append(errs, fmt.Sprintf("unit must be kg or lbs, got %q", req.Unit))
+	}
+	if req.Bodyweight < 0 {
+		errs = append(errs, "bodyweight must not be negative")
+	}
+
+	o := &onboarding{Profile: Profile{ID: profileID, Unit: unit}, ExerciseConfigs: []ExerciseConfig{}, TrainingMaxes: []TrainingMax{}, Goals: []Goal{}}
+	if req.Bodyweight > 0 {
+		// BodyMetrics stores a plain float, so the conversion's tail is
+		// rounded off here rather than on output
+		o.Metrics = &BodyMetrics{Bodyweight: Weight(req.Bodyweight * toKg).Round(config.WeightPrecision)}
+	}
+
+	seen := map[string]bool{}
+	for i, l := range req.Lifts {
+		exercise := strings.TrimSpace(l.Exercise)
+		switch {
+		case exercise == "":
+			errs = append(errs, fmt.Sprintf("lifts[%d].exercise must not be blank", i))
+		case seen[exercise]:
+			errs = append(errs, fmt.Sprintf("lifts[%d]: %s is listed twice", i, exercise))
+		}
+		if l.OneRM <= 0 {
+			errs = append(errs, fmt.Sprintf("lifts[%d].one_rm must be positive", i))
+		}
+		seen[exercise] = true
+		oneRM := l.OneRM * toKg
+		// Start a couple of reps shy of a max effort at the default rep count
+		start := config.roundToLoadable(repWeightFormulas["epley"](oneRM, config.DefaultStartReps+2), config.LoadRounding)
+		o.ExerciseConfigs = append(o.ExerciseConfigs, ExerciseConfig{
+			Exercise:        exercise,
+			StartingWeight:  Weight(start),
+			StartingReps:    config.DefaultStartReps,
+			ProgressionRate: 1,
+		})
+		o.TrainingMaxes = append(o.TrainingMaxes, TrainingMax{Lift: exercise, Weight: Weight(config.roundToLoadable(0.9*oneRM, config.LoadRounding))})
+	}
+
+	seen = map[string]bool{}
+	for i, g := range req.Goals {
+		exercise := strings.TrimSpace(g.Exercise)
+		switch {
+		case exercise == "":
+			errs = append(errs, fmt.Sprintf("goals[%d].exercise must not be blank", i))
+		case seen[exercise]:
+			errs = append(errs, fmt.Sprintf("goals[%d]: %s is listed twice", i, exercise))
+		}
+		if g.TargetOneRM <= 0 {
+			errs = append(errs, fmt.Sprintf("goals[%d].target_1rm must be positive", i))
+		}
+		seen[exercise] = true
+		o.Goals = append(o.Goals, Goal{Exercise: exercise, TargetOneRM: Weight(g.TargetOneRM * toKg)})
+	}
+	return o, errs
+}
+
+// POST /api/v1/onboarding
+// {"unit": "lbs", "bodyweight": 180, "lifts": [{"exercise": "Squat", "one_rm": 315}],
+//
+//	"goals": [{"exercise": "Squat", "target_1rm": 405}]}
+//
+// Lift maxes seed each exercise's starting target and a 90% training max.
+// Existing exercise configs keep their settings apart from the starting
+// target.
+func onboard(repo OnboardingRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req onboardingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		o, errs := req.plan(config)
+		if len(errs) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid onboarding data", "errors": errs})
+			return
+		}
+		if err := repo.Onboard(o); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, o)
+	}
+}
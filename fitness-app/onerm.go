@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type oneRMFormula struct {
+	Name     string
+	Estimate func(weight float64, reps int) float64
+}
+
+// All formulas agree that a single rep is the 1RM.
+var oneRMFormulas = []oneRMFormula{
+	{"epley", estimateOneRM},
+	{"brzycki", func(w float64, r int) float64 {
+		if r <= 1 {
+			return w
+		}
+		if r >= 37 { // Formula breaks down at high reps
+			return 0
+		}
+		return w * 36 / float64(37-r)
+	}},
+	{"lombardi", func(w float64, r int) float64 {
+		if r <= 1 {
+			return w
+		}
+		return w * math.Pow(float64(r), 0.10)
+	}},
+	{"oconner", func(w float64, r int) float64 {
+		if r <= 1 {
+			return w
+		}
+		return w * (1 + 0.025*float64(r))
+	}},
+}
+
+// GET /api/v1/onerm/compare?exercise=Deadlift
+// Estimates from the best AMRAP set when there is one, see oneRMSet.
+func compareOneRM(repo WorkoutRepository, configs ExerciseConfigRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exercise := canonicalExercise(c, configs, c.Query("exercise"))
+		if exercise == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exercise is required"})
+			return
+		}
+
+		best, source, ok := oneRMSet(repo, exercise)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no sets logged for " + exercise})
+			return
+		}
+
+		estimates := gin.H{}
+		low, high := math.Inf(1), math.Inf(-1)
+		for _, f := range oneRMFormulas {
+			v := f.Estimate(float64(best.Weight), best.Reps)
+			if v <= 0 {
+				estimates[f.Name] = nil
+				continue
+			}
+			estimates[f.Name] = Weight(v)
+			low, high = math.Min(low, v), math.Max(high, v)
+		}
+
+		spread := 0.0
+		if high > low {
+			spread = high - low
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"exercise":  exercise,
+			"set":       best,
+			"source":    source,
+			"estimates": estimates,
+			"spread":    Weight(spread),
+		})
+	}
+}
+
+// Inverse formulas: the weight expected to be liftable for the given reps
+var repWeightFormulas = map[string]func(oneRM float64, reps int) float64{
+	"epley": func(m float64, r int) float64 {
+		if r <= 1 {
+			return m
+		}
+		return m / (1 + float64(r)/30)
+	},
+	"brzycki": func(m float64, r int) float64 {
+		if r <= 1 {
+			return m
+		}
+		return m * float64(37-r) / 36
+	},
+}
+
+var repMaxTargets = []int{1, 3, 5, 8, 10, 12}
+
+// GET /api/v1/repmax?exercise=Squat[&formula=brzycki][&rounding=floor]
+func getRepMaxTable(repo WorkoutRepository, configs ExerciseConfigRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exercise := canonicalExercise(c, configs, c.Query("exercise"))
+		if exercise == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exercise is required"})
+			return
+		}
+		formula := c.DefaultQuery("formula", "epley")
+		toWeight, ok := repWeightFormulas[formula]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "formula must be epley or brzycki"})
+			return
+		}
+		rounding, err := config.roundingFor(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		best, source, ok := oneRMSet(repo, exercise)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no sets logged for " + exercise})
+			return
+		}
+		oneRM := estimateOneRM(float64(best.Weight), best.Reps)
+
+		table := make([]gin.H, 0, len(repMaxTargets))
+		for _, reps := range repMaxTargets {
+			table = append(table, gin.H{
+				"reps":   reps,
+				"weight": Weight(config.roundToLoadable(toWeight(oneRM, reps), rounding)),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"exercise":      exercise,
+			"formula":       formula,
+			"estimated_1rm": Weight(oneRM),
+			"source_set":    best,
+			"source":        source,
+			"table":         table,
+		})
+	}
+}
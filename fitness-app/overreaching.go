@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Each side of the RPE trend needs this many sets with an RPE logged
+const minRPETrendSets = 3
+
+// rpeTrend is the average RPE of the last 7 days against the 21 days before
+type rpeTrend struct {
+	Recent       *float64 `json:"recent"`
+	Baseline     *float64 `json:"baseline"`
+	Change       *float64 `json:"change"`
+	RecentSets   int      `json:"recent_sets"`
+	BaselineSets int      `json:"baseline_sets"`
+}
+
+func rpeTrendOf(recent []Workout, now time.Time, config Config) (rpeTrend, bool) {
+	acuteStart := now.AddDate(0, 0, -7)
+	var t rpeTrend
+	recentSum, baselineSum := 0, 0
+	for _, w := range recent {
+		if w.RPE == 0 || !config.isWorkingSet(w) {
+			continue
+		}
+		if w.CreatedAt.After(acuteStart) {
+			recentSum += w.RPE
+			t.RecentSets++
+		} else {
+			baselineSum += w.RPE
+			t.BaselineSets++
+		}
+	}
+	if t.RecentSets < minRPETrendSets || t.BaselineSets < minRPETrendSets {
+		return t, false
+	}
+	round := func(v float64) *float64 {
+		v = math.Round(v*10) / 10
+		return &v
+	}
+	avgRecent := float64(recentSum) / float64(t.RecentSets)
+	avgBaseline := float64(baselineSum) / float64(t.BaselineSets)
+	t.Recent, t.Baseline, t.Change = round(avgRecent), round(avgBaseline), round(avgRecent-avgBaseline)
+	return t, true
+}
+
+// GET /api/v1/overreaching[?set_type=...|&exclude_set_type=...]
+// Combines the ACWR with the working sets' RPE trend: load spiking past
+// ACWR_RISK_THRESHOLD and sets feeling RPE_RISE_THRESHOLD harder than the
+// three weeks before are each a factor. Neither is low, one moderate, both
+// high. Either signal lacking data makes the level "unknown"; the numbers
+// behind both come back regardless.
+func getOverreaching(repo WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		types, err := config.setTypeFilters(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		now := time.Now()
+		recent, err := repo.ListWorkouts(WorkoutQuery{Filters: types, From: now.AddDate(0, 0, -28)})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		acute, chronic, ratio, haveRatio, err := workloadRatio(repo, config, recent, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		trend, haveTrend := rpeTrendOf(recent, now, config)
+
+		acwr := gin.H{
+			"acute_load":   Weight(acute),
+			"chronic_load": Weight(chronic),
+			"ratio":        nil,
+			"threshold":    config.ACWRRiskThreshold,
+		}
+		if haveRatio {
+			acwr["ratio"] = math.Round(ratio*100) / 100
+		}
+		res := gin.H{
+			"acwr":      acwr,
+			"rpe_trend": trend,
+			"factors":   []string{},
+		}
+
+		var missing []string
+		if !haveRatio {
+			missing = append(missing, "28 days of history")
+		}
+		if !haveTrend {
+			missing = append(missing, fmt.Sprintf("%d working sets with RPE in both the last 7 days and the 21 before", minRPETrendSets))
+		}
+		if len(missing) > 0 {
+			res["level"] = "unknown"
+			res["message"] = "Need " + strings.Join(missing, " and ")
+			c.JSON(http.StatusOK, res)
+			return
+		}
+
+		factors := []string{}
+		if ratio > config.ACWRRiskThreshold {
+			factors = append(factors, fmt.Sprintf("acute load is %.2f× chronic (threshold %.2f)", ratio, config.ACWRRiskThreshold))
+		}
+		if *trend.Change >= config.RPERiseThreshold {
+			factors = append(factors, fmt.Sprintf("average RPE up %.1f to %.1f (threshold +%.1f)", *trend.Change, *trend.Recent, config.RPERiseThreshold))
+		}
+		res["factors"] = factors
+		res["level"] = []string{"low", "moderate", "high"}[len(factors)]
+		c.JSON(http.StatusOK, res)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Where registerPprof's group is mounted
+const pprofPath = "/debug/pprof"
+
+// registerPprof mounts the net/http/pprof handlers on g, e.g.
+// /debug/pprof/heap or /debug/pprof/profile?seconds=30 for CPU.
+func registerPprof(g *gin.RouterGroup) {
+	g.GET("/", gin.WrapF(pprof.Index))
+	g.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	g.GET("/profile", gin.WrapF(pprof.Profile))
+	g.POST("/symbol", gin.WrapF(pprof.Symbol))
+	g.GET("/symbol", gin.WrapF(pprof.Symbol))
+	g.GET("/trace", gin.WrapF(pprof.Trace))
+	// Named profiles (heap, goroutine, allocs, block, mutex, threadcreate)
+	g.GET("/:profile", func(c *gin.Context) {
+		pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+	})
+}
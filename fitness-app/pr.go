@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Epley estimate, mirrored in SQL so bests can be aggregated in the database.
+const epleySQL = "CASE WHEN reps <= 1 THEN " + loadSQL + " ELSE " + loadSQL + " * (1 + reps / 30.0) END"
+
+type PRHighlight struct {
+	Type     string `json:"type"`     // "all-time" or "weekly"
+	Previous Weight `json:"previous"` // Previous best estimated 1RM
+	Current  Weight `json:"current"`
+}
+
+func estimateOneRM(weight float64, reps int) float64 {
+	if reps <= 1 {
+		return weight
+	}
+	return weight * (1 + float64(reps)/30)
+}
+
+// workoutOneRM is a set's estimated 1RM on its total load
+func workoutOneRM(w Workout) float64 {
+	return estimateOneRM(totalLoad(w), w.Reps)
+}
+
+// Monday 00:00 of the week containing t
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	y, m, d := t.AddDate(0, 0, -offset).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// bestOneRM returns the best estimated 1RM for an exercise since the given time
+// (zero time means all-time) and whether any sets were found. All-time bests
+// come from the exercise_stats table.
+func bestOneRM(repo WorkoutRepository, exercise string, since time.Time) (float64, bool) {
+	if since.IsZero() {
+		stat, ok := findExerciseStat(repo, exercise)
+		return stat.BestOneRM, ok
+	}
+
+	q := exerciseQuery(exercise)
+	q.Filters = append(q.Filters, notWarmup)
+	q.From = since
+	sets, err := repo.ListWorkouts(q)
+	if err != nil || len(sets) == 0 {
+		return 0, false
+	}
+	best := 0.0
+	for _, w := range sets {
+		best = max(best, workoutOneRM(w))
+	}
+	return best, true
+}
+
+// detectPR must run before the workout is inserted so the new set isn't
+// compared against itself. A first-ever set is not celebrated, nor is a
+// warm-up.
+func detectPR(repo WorkoutRepository, w Workout) *PRHighlight {
+	if !countsForPR(w) {
+		return nil
+	}
+	current := workoutOneRM(w)
+
+	allTime, ok := bestOneRM(repo, w.Exercise, time.Time{})
+	if !ok {
+		return nil
+	}
+	if current > allTime {
+		return &PRHighlight{Type: "all-time", Previous: Weight(allTime), Current: Weight(current)}
+	}
+
+	weekly, ok := bestOneRM(repo, w.Exercise, startOfWeek(time.Now()))
+	if ok && current > weekly {
+		return &PRHighlight{Type: "weekly", Previous: Weight(weekly), Current: Weight(current)}
+	}
+	return nil
+}
+
+func prCard(w Workout, pr *PRHighlight) string {
+	label := "NEW ALL-TIME PR"
+	if pr.Type == "weekly" {
+		label = "WEEKLY BEST"
+	}
+	return fmt.Sprintf(`
+				<div class="p-3 bg-slate-700 rounded border-l-4 border-yellow-500 shadow-sm animate-pulse">
+					<div class="text-xs font-black text-yellow-500 tracking-widest">🏆 %s</div>
+					<span class="font-bold text-blue-400">%s</span>: %s reps @ %s
+					<div class="text-xs text-slate-300">Est. 1RM %.1fkg (was %.1fkg)</div>
+				</div>`, label, html.EscapeString(trackedName(w)), repsLabel(w), weightLabel(w), pr.Current, pr.Previous)
+}
+
+// prIDs replays an exercise's sets (oldest first) and returns the IDs of
+// those that beat every earlier estimated 1RM, starting from the archived
+// best (0 if nothing was pruned). The first set ever is never a PR, and
+// warm-ups are skipped.
+func prIDs(sets []Workout, archived float64) []uint {
+	var ids []uint
+	best, seen := archived, archived > 0
+	for _, w := range sets {
+		if !countsForPR(w) {
+			continue
+		}
+		e1rm := workoutOneRM(w)
+		if seen && e1rm > best {
+			ids = append(ids, w.ID)
+		}
+		if !seen || e1rm > best {
+			best = e1rm
+		}
+		seen = true
+	}
+	return ids
+}
+
+// Every set flagged as an all-time PR
+var prQuery = WorkoutQuery{Filters: []filterCond{{Column: "is_pr", Value: true}}}
+
+// recomputePRs rewrites the is_pr flags for one exercise by replaying its
+// history in order. UpdateColumn is used so the Workout hooks don't recurse.
+func recomputePRs(tx *gorm.DB, exercise string, config Config) error {
+	var sets []Workout
+	if err := tx.Where("exercise = ?", exercise).Order("created_at asc, id asc").Find(&sets).Error; err != nil {
+		return err
+	}
+
+	archive, err := findArchive(tx, exercise)
+	if err != nil {
+		return err
+	}
+	ids := prIDs(sets, archive.BestOneRM)
+	if err := tx.Model(&Workout{}).Where("exercise = ?", exercise).UpdateColumn("is_pr", false).Error; err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		if err := tx.Model(&Workout{}).Where("id IN ?", ids).UpdateColumn("is_pr", true).Error; err != nil {
+			return err
+		}
+	}
+
+	// Weekly PR counts only need refreshing across the flags that flipped
+	prs := map[uint]bool{}
+	for _, id := range ids {
+		prs[id] = true
+	}
+	var first, last time.Time
+	for _, w := range sets {
+		if w.IsPR != prs[w.ID] {
+			if first.IsZero() {
+				first = w.CreatedAt
+			}
+			last = w.CreatedAt
+		}
+	}
+	if first.IsZero() {
+		return nil
+	}
+	return refreshWeeklySummaries(tx, first, last, config)
+}
+
+// POST /api/v1/maintenance/backfill-prs
+func backfillPRs(repo WorkoutRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exercises, err := repo.WorkoutExercises()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := repo.RecomputePRs(exercises); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		prs, _ := repo.CountWorkouts(prQuery)
+		c.JSON(http.StatusOK, gin.H{"exercises": len(exercises), "prs": prs})
+	}
+}
+
+type prCardSet struct {
+	Weight    Weight    `json:"weight"`
+	Reps      int       `json:"reps"`
+	PerSide   bool      `json:"per_side"`
+	OneRM     Weight    `json:"estimated_1rm"`
+	Date      string    `json:"date"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func newPRCardSet(w Workout) prCardSet {
+	return prCardSet{
+		Weight:    w.Weight,
+		Reps:      w.Reps,
+		PerSide:   bool(w.PerSide),
+		OneRM:     Weight(workoutOneRM(w)),
+		Date:      dayKey(w.CreatedAt),
+		Timestamp: w.CreatedAt,
+	}
+}
+
+// GET /api/v1/prs/card?exercise=Deadlift
+// The exercise's latest all-time PR as a flat payload for rendering a share
+// graphic. previous is the best set before it; null if that was pruned.
+func getPRCard(repo WorkoutRepository, configs ExerciseConfigRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exercise := canonicalExercise(c, configs, c.Query("exercise"))
+		if exercise == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exercise is required"})
+			return
+		}
+		q := exerciseQuery(exercise)
+		q.Filters = append(q.Filters, prQuery.Filters...)
+		pr, ok := lastWorkout(repo, q)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no PR for " + exercise})
+			return
+		}
+
+		earlier := exerciseQuery(exercise)
+		earlier.Filters = append(earlier.Filters, notWarmup)
+		earlier.To = pr.CreatedAt
+		sets, err := repo.ListWorkouts(earlier)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var previous *prCardSet
+		for _, w := range sets {
+			if w.ID == pr.ID || (previous != nil && workoutOneRM(w) <= float64(previous.OneRM)) {
+				continue
+			}
+			p := newPRCardSet(w)
+			previous = &p
+		}
+
+		card := newPRCardSet(pr)
+		resp := gin.H{
+			"exercise": pr.Exercise,
+			"pr":       card,
+			"previous": previous,
+		}
+		if previous != nil && previous.OneRM > 0 {
+			gain := card.OneRM - previous.OneRM
+			resp["improvement"] = gin.H{
+				"kg":  gain,
+				"pct": math.Round(float64(gain)/float64(previous.OneRM)*1000) / 10,
+			}
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+type repPR struct {
+	Reps      int       `json:"reps"`
+	Weight    Weight    `json:"weight"`
+	PerSide   bool      `json:"per_side"`
+	WorkoutID uint      `json:"workout_id"`
+	Date      string    `json:"date"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// GET /api/v1/rep-prs?exercise=Squat[&variation=pause][&set_type=...]
+// The heaviest set at each rep count (best single, triple, 5RM...), fewest
+// reps first. Per-side sets compare on their total load; warm-ups never
+// count.
+func getRepPRs(repo WorkoutRepository, configs ExerciseConfigRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exercise := canonicalExercise(c, configs, c.Query("exercise"))
+		if exercise == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exercise is required"})
+			return
+		}
+		variation := strings.TrimSpace(c.Query("variation"))
+		q := variationQuery(exercise, variation)
+		types, err := config.setTypeFilters(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		q.Filters = append(append(q.Filters, notWarmup), types...)
+		best, err := repo.BestByReps(q)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(best) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no sets logged for " + trackedName(Workout{Exercise: exercise, Variation: variation})})
+			return
+		}
+
+		prs := make([]repPR, len(best))
+		for i, w := range best {
+			prs[i] = repPR{
+				Reps:      w.Reps,
+				Weight:    w.Weight,
+				PerSide:   bool(w.PerSide),
+				WorkoutID: w.ID,
+				Date:      dayKey(w.CreatedAt),
+				Timestamp: w.CreatedAt,
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"exercise": exercise, "variation": variation, "prs": prs})
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrainingMax rows are append-only; the latest row per lift is current.
+// This keeps the history of every cycle bump.
+type TrainingMax struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Lift      string    `gorm:"index" json:"lift" binding:"required"`
+	Weight    Weight    `json:"weight" binding:"required"`
+	Increment Weight    `json:"increment"`
+	CreatedAt time.Time `json:"timestamp"`
+}
+
+var lowerBodyMuscles = []string{"legs", "quads", "hamstrings", "glutes", "calves"}
+
+// isLowerBody decides which 5/3/1 increment applies to a lift, by name first
+// and then by the muscle group it was last logged with.
+func isLowerBody(repo WorkoutRepository, lift string) bool {
+	name := strings.ToLower(lift)
+	if strings.Contains(name, "squat") || strings.Contains(name, "deadlift") {
+		return true
+	}
+	last, ok := lastWorkout(repo, exerciseQuery(lift))
+	if !ok {
+		return false
+	}
+	group := strings.ToLower(last.MuscleGroup)
+	for _, m := range lowerBodyMuscles {
+		if group == m {
+			return true
+		}
+	}
+	return false
+}
+
+func currentTrainingMax(repo ProgramRepository, lift string) (TrainingMax, bool) {
+	tm, err := repo.CurrentTrainingMax(lift)
+	return tm, err == nil
+}
+
+// POST /api/v1/program/training-max sets a lift's training max directly
+func setTrainingMax(repo ProgramRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tm TrainingMax
+		if err := c.ShouldBind(&tm); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		tm.ID, tm.Increment = 0, 0
+		tm.Lift = strings.TrimSpace(tm.Lift)
+		if tm.Lift == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lift must not be blank"})
+			return
+		}
+		if err := repo.CreateTrainingMax(&tm); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, tm)
+	}
+}
+
+// POST /api/v1/program/advance?lift=Squat
+// Bumps the training max after a successful cycle: +2.5kg for upper body,
+// +5kg for lower body by default.
+func advanceTrainingMax(repo ProgramRepository, workouts WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lift := c.Query("lift")
+		if lift == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lift is required"})
+			return
+		}
+		current, ok := currentTrainingMax(repo, lift)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no training max set for " + lift})
+			return
+		}
+
+		increment := config.TMIncrementUpper
+		if isLowerBody(workouts, lift) {
+			increment = config.TMIncrementLower
+		}
+		next := TrainingMax{Lift: lift, Weight: current.Weight + Weight(increment), Increment: Weight(increment)}
+		if err := repo.CreateTrainingMax(&next); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"lift":         lift,
+			"previous":     current.Weight,
+			"training_max": next.Weight,
+			"increment":    next.Increment,
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// User input never reaches SQL directly: sort keys and filter params are
+// looked up in these allowlists, and only the mapped column names are used.
+// Filter values are always bound as parameters.
+
+// Sortable columns for the workout list
+var workoutSortColumns = map[string]string{
+	"created_at": "created_at",
+	"weight":     "weight",
+	"reps":       "reps",
+	"exercise":   "exercise",
+}
+
+// Query params that filter the workout list by exact match
+var workoutFilterColumns = []struct{ Param, Column string }{
+	{"exercise", "exercise"},
+	{"variation", "variation"},
+	{"muscle_group", "muscle_group"},
+	{"equipment", "equipment"},
+	{"is_failure", "is_failure"},
+	{"is_amrap", "is_amrap"},
+}
+
+// parseSort turns ?sort=&order= into a sort order, defaulting to newest
+// first.
+func parseSort(sort, order string) (sortOrder, error) {
+	if sort == "" {
+		sort = "created_at"
+	}
+	column, ok := workoutSortColumns[sort]
+	if !ok {
+		return sortOrder{}, fmt.Errorf("invalid sort %q (allowed: created_at, weight, reps, exercise)", sort)
+	}
+
+	var desc bool
+	switch strings.ToLower(order) {
+	case "", "desc":
+		desc = true
+	case "asc":
+		desc = false
+	default:
+		return sortOrder{}, fmt.Errorf("invalid order %q (allowed: asc, desc)", order)
+	}
+	return sortOrder{Column: column, Desc: desc}, nil
+}
+
+// A filterCond matches Column exactly, or any of a []string Value; Not
+// inverts it
+type filterCond struct {
+	Column string
+	Value  interface{}
+	Not    bool
+}
+
+// parseFilters collects the allowlisted filter params present in the query.
+// Unknown params are ignored so other query options can coexist, as are
+// empty boolean params (?is_failure=), which would otherwise read as false.
+func parseFilters(query map[string][]string, config Config) ([]filterCond, error) {
+	var conds []filterCond
+	for _, f := range workoutFilterColumns {
+		values, ok := query[f.Param]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		var value interface{} = values[0]
+		if f.Param == "is_failure" || f.Param == "is_amrap" {
+			if strings.TrimSpace(values[0]) == "" {
+				continue
+			}
+			b, err := parseFlexBool(values[0])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Param, err)
+			}
+			value = b
+		}
+		conds = append(conds, filterCond{Column: f.Column, Value: value})
+	}
+	types, err := config.setTypeFilters(query)
+	if err != nil {
+		return nil, err
+	}
+	return append(conds, types...), nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestParseFilters(t *testing.T) {
+	config := defaultConfig()
+	tests := []struct {
+		name    string
+		query   string
+		want    []filterCond
+		wantErr bool
+	}{
+		{
+			name:  "no params",
+			query: "",
+		},
+		{
+			name:  "values pass through verbatim to be bound",
+			query: "exercise=" + url.QueryEscape("Squat' OR '1'='1"),
+			want:  []filterCond{{Column: "exercise", Value: "Squat' OR '1'='1"}},
+		},
+		{
+			name:  "statement terminators stay in the value",
+			query: "muscle_group=" + url.QueryEscape("quads; DROP TABLE workouts;--"),
+			want:  []filterCond{{Column: "muscle_group", Value: "quads; DROP TABLE workouts;--"}},
+		},
+		{
+			name:  "only the first of repeated params is used",
+			query: "equipment=barbell&equipment=" + url.QueryEscape("x') OR 1=1--"),
+			want:  []filterCond{{Column: "equipment", Value: "barbell"}},
+		},
+		{
+			name:  "params outside the allowlist are ignored",
+			query: "password=x&" + url.QueryEscape("exercise; DELETE FROM workouts") + "=1&id=1",
+		},
+		{
+			name:  "booleans are parsed",
+			query: "is_failure=yes&is_amrap=0",
+			want:  []filterCond{{Column: "is_failure", Value: true}, {Column: "is_amrap", Value: false}},
+		},
+		{
+			name:    "a non-boolean is rejected",
+			query:   "is_failure=" + url.QueryEscape("true OR 1=1"),
+			wantErr: true,
+		},
+		{
+			name:  "an empty boolean is ignored",
+			query: "is_failure=&is_amrap=%20",
+		},
+		{
+			name:  "set types are normalized",
+			query: "set_type=Working,%20BACKOFF",
+			want:  []filterCond{{Column: "set_type", Value: []string{"working", "backoff"}}},
+		},
+		{
+			name:    "an unknown set type is rejected",
+			query:   "set_type=" + url.QueryEscape("warmup' OR '1'='1"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("bad test query: %v", err)
+			}
+			got, err := parseFilters(query, config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListWorkoutsInjection(t *testing.T) {
+	app := newTestApp(t, nil)
+	seed := []Workout{
+		{Exercise: "Squat", Weight: 100, Reps: 5, IsFailure: true},
+		{Exercise: "Bench", Weight: 80, Reps: 8},
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		sets   int
+	}{
+		{"tautology in a filter matches nothing", "exercise=" + url.QueryEscape("Squat' OR '1'='1"), http.StatusOK, 0},
+		{"comment in a filter matches nothing", "muscle_group=" + url.QueryEscape("x'--"), http.StatusOK, 0},
+		{"injected sort column is rejected", "sort=" + url.QueryEscape("weight; DROP TABLE workouts"), http.StatusBadRequest, 0},
+		{"injected sort order is rejected", "order=" + url.QueryEscape("asc, (SELECT 1)"), http.StatusBadRequest, 0},
+		{"injected boolean is rejected", "is_failure=" + url.QueryEscape("1 OR 1=1"), http.StatusBadRequest, 0},
+		{"empty boolean doesn't filter", "is_failure=", http.StatusOK, 2},
+		{"boolean filters", "is_failure=true", http.StatusOK, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app.t = t
+			app.reset()
+			app.seed(append([]Workout(nil), seed...)...)
+			rec := app.do(http.MethodGet, "/api/v1/workouts?"+tt.query, nil)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var got []Workout
+			decode(t, rec, &got)
+			if len(got) != tt.sets {
+				t.Errorf("got %d sets, want %d", len(got), tt.sets)
+			}
+		})
+	}
+}
+
+// A Postgres store in dry-run mode: queries are built but never sent, so
+// the statements can be inspected without a database
+func dryRunGormRepository(t *testing.T) (*gormRepository, *[]*gorm.Statement) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=dry_run"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("opening dry-run db: %v", err)
+	}
+	var statements []*gorm.Statement
+	err = db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		if tx.Statement.Table == "workouts" {
+			statements = append(statements, tx.Statement)
+		}
+	})
+	if err != nil {
+		t.Fatalf("registering capture: %v", err)
+	}
+	return newGormRepository(db, defaultConfig()), &statements
+}
+
+func TestListWorkoutsInjectionSQL(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		sql    string   // Must appear in the statement
+		bound  []string // Must be bound, not in the SQL text
+		notSQL []string // Must not appear in the SQL text
+	}{
+		{
+			name:   "filter values are bound",
+			query:  "exercise=" + url.QueryEscape("Squat' OR '1'='1") + "&muscle_group=" + url.QueryEscape("x'; DROP TABLE workouts;--"),
+			status: http.StatusOK,
+			sql:    `"exercise" = $1 AND "muscle_group" = $2`,
+			bound:  []string{"Squat' OR '1'='1", "x'; DROP TABLE workouts;--"},
+			notSQL: []string{"OR '1'='1", "DROP TABLE"},
+		},
+		{
+			name:   "sort columns come from the allowlist, quoted",
+			query:  "sort=weight&order=asc",
+			status: http.StatusOK,
+			sql:    `ORDER BY "weight","id"`,
+		},
+		{
+			name:   "an injected sort never reaches the store",
+			query:  "sort=" + url.QueryEscape("weight; DROP TABLE workouts"),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "an injected order never reaches the store",
+			query:  "order=" + url.QueryEscape("asc, (SELECT 1)"),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, statements := dryRunGormRepository(t)
+			config, err := loadConfig(func(key string) string { return testEnv[key] })
+			if err != nil {
+				t.Fatalf("config: %v", err)
+			}
+			rec := httptest.NewRecorder()
+			newRouter(repo, config).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/workouts?"+tt.query, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				if len(*statements) != 0 {
+					t.Errorf("queried workouts: %s", (*statements)[0].SQL.String())
+				}
+				return
+			}
+			if len(*statements) != 1 {
+				t.Fatalf("got %d workout queries, want 1", len(*statements))
+			}
+			stmt := (*statements)[0]
+			sql := stmt.SQL.String()
+			if !strings.Contains(sql, tt.sql) {
+				t.Errorf("SQL %q doesn't contain %q", sql, tt.sql)
+			}
+			for _, s := range tt.notSQL {
+				if strings.Contains(sql, s) {
+					t.Errorf("SQL %q contains %q", sql, s)
+				}
+			}
+			for _, v := range tt.bound {
+				if !slices.Contains(stmt.Vars, interface{}(v)) {
+					t.Errorf("%q isn't among the bound vars %v", v, stmt.Vars)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// recoveryWarning checks whether the muscle group was trained in an earlier
+// session within the recovery window. Sets from today count as the same
+// session and are ignored.
+func recoveryWarning(repo WorkoutRepository, config Config, w Workout, now time.Time) (Warning, bool) {
+	if w.MuscleGroup == "" || config.MuscleRecoveryHours <= 0 {
+		return Warning{}, false
+	}
+	last, ok := lastWorkout(repo, WorkoutQuery{
+		Filters: []filterCond{{Column: "muscle_group", Value: w.MuscleGroup}},
+		To:      startOfDay(now).Add(-time.Nanosecond),
+	})
+	if !ok {
+		return Warning{}, false
+	}
+
+	since := now.Sub(last.CreatedAt)
+	if since >= time.Duration(config.MuscleRecoveryHours)*time.Hour {
+		return Warning{}, false
+	}
+	return Warning{warnRecovery, fmt.Sprintf("%s was trained %.0fh ago (recovery window %dh)", w.MuscleGroup, since.Hours(), config.MuscleRecoveryHours)}, true
+}
+
+// The recovery score's default window; older sessions don't feed it or
+// its baseline
+const recoveryLookbackDays = 28
+
+type muscleRecovery struct {
+	MuscleGroup string    `json:"muscle_group"`
+	Score       int       `json:"score"` // 0 just trained, 100 ready
+	LastTrained time.Time `json:"last_trained"`
+	HoursSince  float64   `json:"hours_since"`
+	// Inputs from the last session, against the muscle's typical session
+	Sets          int     `json:"sets"`
+	Volume        Weight  `json:"volume"`
+	AvgVolume     Weight  `json:"avg_volume"`
+	VolumeRatio   float64 `json:"volume_ratio"`
+	AvgRPE        float64 `json:"avg_rpe"` // 0 when no set had an RPE
+	HoursRequired float64 `json:"hours_required"`
+}
+
+// recoveryScore scales MUSCLE_RECOVERY_HOURS by how big and hard the last
+// session was: double the usual volume needs up to twice as long, and each
+// RPE point above 8 adds 10%. The score is the share of that time elapsed.
+func recoveryScore(r *muscleRecovery, recoveryHours int) {
+	ratio := 1.0
+	if r.AvgVolume > 0 {
+		ratio = float64(r.Volume / r.AvgVolume)
+	}
+	r.VolumeRatio = math.Round(ratio*100) / 100
+	rpeFactor := 1.0
+	if r.AvgRPE > 0 {
+		rpeFactor = 1 + 0.1*(r.AvgRPE-8)
+	}
+	required := float64(recoveryHours) * min(max(ratio, 0.5), 2) * rpeFactor
+	r.HoursRequired = math.Round(required*10) / 10
+	r.Score = 100
+	if required > 0 {
+		r.Score = int(min(math.Round(100*r.HoursSince/required), 100))
+	}
+}
+
+// GET /api/v1/recovery/score
+// A 0-100 readiness score per muscle group trained in the window (by
+// default the last four weeks), least recovered first, with the inputs
+// behind it. Scores are as of the window's end.
+func getRecoveryScore(repo WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, now, err := parseWindow(c, recoveryLookbackDays)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		workouts, err := repo.ListWorkouts(WorkoutQuery{From: from, To: now})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		type session struct {
+			day    string
+			last   time.Time
+			sets   int
+			volume float64
+			rpeSum int
+			rpeN   int
+		}
+		// Working sets per muscle group and day, oldest first
+		sessions := map[string][]*session{}
+		for _, w := range workouts {
+			if w.MuscleGroup == "" || !config.isWorkingSet(w) {
+				continue
+			}
+			group, day := strings.ToLower(w.MuscleGroup), dayKey(w.CreatedAt)
+			list := sessions[group]
+			if n := len(list); n == 0 || list[n-1].day != day {
+				list = append(list, &session{day: day})
+			}
+			s := list[len(list)-1]
+			s.last = w.CreatedAt
+			s.sets++
+			s.volume += config.workoutVolume(w)
+			if w.RPE > 0 {
+				s.rpeSum += w.RPE
+				s.rpeN++
+			}
+			sessions[group] = list
+		}
+
+		scores := make([]muscleRecovery, 0, len(sessions))
+		for group, list := range sessions {
+			last := list[len(list)-1]
+			r := muscleRecovery{
+				MuscleGroup: group,
+				LastTrained: last.last,
+				HoursSince:  math.Round(now.Sub(last.last).Hours()*10) / 10,
+				Sets:        last.sets,
+				Volume:      Weight(last.volume),
+			}
+			if last.rpeN > 0 {
+				r.AvgRPE = math.Round(float64(last.rpeSum)/float64(last.rpeN)*10) / 10
+			}
+			if earlier := list[:len(list)-1]; len(earlier) > 0 {
+				for _, s := range earlier {
+					r.AvgVolume += Weight(s.volume)
+				}
+				r.AvgVolume /= Weight(len(earlier))
+			}
+			recoveryScore(&r, config.MuscleRecoveryHours)
+			scores = append(scores, r)
+		}
+		sort.Slice(scores, func(i, j int) bool {
+			if scores[i].Score != scores[j].Score {
+				return scores[i].Score < scores[j].Score
+			}
+			return scores[i].MuscleGroup < scores[j].MuscleGroup
+		})
+		c.JSON(http.StatusOK, gin.H{
+			"base_recovery_hours": config.MuscleRecoveryHours,
+			"muscles":             scores,
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errNotFound is returned by lookups that match nothing, whatever the backend
+var errNotFound = errors.New("record not found")
+
+// sortOrder is a column from workoutSortColumns and a direction
+type sortOrder struct {
+	Column string
+	Desc   bool
+}
+
+// WorkoutQuery selects workouts. Zero values mean no constraint; results
+// are oldest first unless Sort says otherwise.
+type WorkoutQuery struct {
+	Filters []filterCond
+	From    time.Time // Inclusive; zero means unbounded
+	To      time.Time // Inclusive; zero means unbounded
+	Sort    sortOrder
+	Limit   int
+	// Only sets that stand on their own: no warm-ups, and no drops after a
+	// drop set's first
+	TopSets bool
+}
+
+func exerciseQuery(exercise string) WorkoutQuery {
+	return WorkoutQuery{Filters: []filterCond{{Column: "exercise", Value: exercise}}}
+}
+
+// variationQuery narrows exerciseQuery to one variation; "" is the plain
+// exercise without a modifier
+func variationQuery(exercise, variation string) WorkoutQuery {
+	q := exerciseQuery(exercise)
+	q.Filters = append(q.Filters, filterCond{Column: "variation", Value: variation})
+	return q
+}
+
+// Aggregates over the whole workout history, for /experience
+type workoutSummary struct {
+	Sessions    int64
+	ActiveWeeks int64
+	FirstLog    *time.Time
+	Volume      float64
+	Sets        int64
+}
+
+// WorkoutRepository stores sets and the data derived from them (PR flags
+// and per-exercise stats).
+type WorkoutRepository interface {
+	// CreateWorkout stores a set and any drops done straight after it, all
+	// or nothing. With drops, every row gets the set's ID as DropSetID.
+	CreateWorkout(w *Workout, drops []Workout) error
+	// ImportWorkouts stores historical sets all or nothing, then recomputes
+	// PR flags for the exercises they touch
+	ImportWorkouts(ws []Workout) error
+	GetWorkout(id uint) (Workout, error)
+	ListWorkouts(q WorkoutQuery) ([]Workout, error)
+	CountWorkouts(q WorkoutQuery) (int64, error)
+	// BestByReps is the heaviest matching set at each rep count, fewest
+	// reps first; ties go to the earliest
+	BestByReps(q WorkoutQuery) ([]Workout, error)
+	WorkoutExercises() ([]string, error)
+	// WorkoutSummary aggregates the sets logged from from to to; sessions
+	// and active weeks also count cardio. With a zero from it covers full
+	// history, pruned archives included.
+	WorkoutSummary(from, to time.Time) (workoutSummary, error)
+	EachWorkoutBatch(size int, fn func([]Workout) error) error
+	WorkoutExportMeta() (exportMeta, error)
+	// UpdateTags replaces the tags of each workout ID in one transaction
+	UpdateTags(tags map[uint]Tags) error
+	// UpdateMuscleGroups sets the muscle group of each workout ID in one
+	// transaction
+	UpdateMuscleGroups(groups map[uint]string) error
+	// RecomputePRs replays each exercise's history to rewrite the is_pr flags
+	RecomputePRs(exercises []string) error
+	// PruneHistory archives and deletes the exercise's sets from all but its
+	// last keepSessions training days, returning how many went
+	PruneHistory(exercise string, keepSessions int) (int, error)
+
+	FindExerciseStat(exercise string) (ExerciseStat, error)
+	// RebuildExerciseStats recreates every exercise's stat row from its sets
+	RebuildExerciseStats() (int, error)
+
+	// ListWeeklySummaries is the stored rollup rows of the weeks starting
+	// from from to to, oldest first
+	ListWeeklySummaries(from, to time.Time) ([]WeeklySummary, error)
+	// RebuildWeeklySummaries recreates every week's rollup from the sets and
+	// the pruned archive, returning the number of weeks and the rows that
+	// disagreed
+	RebuildWeeklySummaries() (int, []summaryDrift, error)
+}
+
+type MetricsRepository interface {
+	CreateMetrics(m *BodyMetrics) error
+	GetMetrics(id uint) (BodyMetrics, error)
+	SaveMetrics(m *BodyMetrics) error
+	ListMetrics() ([]BodyMetrics, error)
+	LastMetrics() (BodyMetrics, error)
+	EachMetricsBatch(size int, fn func([]BodyMetrics) error) error
+	MetricsExportMeta() (exportMeta, error)
+}
+
+type CardioRepository interface {
+	CreateCardio(entry *Cardio) error
+	GetCardio(id uint) (Cardio, error)
+	// ListCardio is every entry, newest first
+	ListCardio() ([]Cardio, error)
+	SaveCardio(entry *Cardio) error
+	DeleteCardio(id uint) (bool, error)
+}
+
+type ExerciseConfigRepository interface {
+	FindExerciseConfig(exercise string) (ExerciseConfig, error)
+	ListExerciseConfigs() ([]ExerciseConfig, error)
+	SaveExerciseConfig(cfg *ExerciseConfig) error
+	DeleteExerciseConfig(exercise string) (bool, error)
+	// SeedExerciseConfigs inserts the configs only if there are none yet
+	SeedExerciseConfigs(configs []ExerciseConfig) (int64, error)
+}
+
+type ProgramRepository interface {
+	CurrentTrainingMax(lift string) (TrainingMax, error)
+	// ListTrainingMaxes is the current training max of every lift, by lift
+	ListTrainingMaxes() ([]TrainingMax, error)
+	CreateTrainingMax(tm *TrainingMax) error
+}
+
+// OnboardingRepository stores the first-run profile and goals
+type OnboardingRepository interface {
+	// Onboard writes everything in o in one transaction, filling in IDs.
+	// Exercise configs that already exist only get their starting target
+	// updated; goals replace any earlier goal for the same exercise.
+	Onboard(o *onboarding) error
+}
+
+type GoalRepository interface {
+	FindGoal(exercise string) (Goal, error)
+	ListGoals() ([]Goal, error)
+	SaveGoal(g *Goal) error
+	DeleteGoal(exercise string) (bool, error)
+}
+
+// SessionRepository stores the names given to training days
+type SessionRepository interface {
+	FindSessionName(date string) (SessionName, error)
+	ListSessionNames() ([]SessionName, error)
+	SaveSessionName(n *SessionName) error
+	DeleteSessionName(date string) (bool, error)
+}
+
+// AdminRepository backs the health and maintenance endpoints. Stores
+// without planner statistics report no tables to analyze.
+type AdminRepository interface {
+	Ping(ctx context.Context) error
+	Tables() ([]string, error)
+	Analyze(ctx context.Context, table string, vacuum bool) error
+	// Reset deletes every row in every table
+	Reset(ctx context.Context) error
+}
+
+// Repository is all the storage the app uses. Postgres (via GORM) is the
+// default; DB_DRIVER=memory swaps in a process-local store for demos and
+// tests. Handlers take only the parts they need.
+type Repository interface {
+	WorkoutRepository
+	MetricsRepository
+	CardioRepository
+	ExerciseConfigRepository
+	ProgramRepository
+	GoalRepository
+	SessionRepository
+	OnboardingRepository
+	AdminRepository
+}
+
+// openStore picks the Repository for DB_DRIVER
+func openStore(c Config) Repository {
+	if c.DB.Driver == "memory" {
+		return newMemoryRepository(c)
+	}
+	return newGormRepository(initDatabase(c), c)
+}
+
+// lastWorkout is the most recent set matching q
+func lastWorkout(workouts WorkoutRepository, q WorkoutQuery) (Workout, bool) {
+	q.Sort, q.Limit = sortOrder{Column: "created_at", Desc: true}, 1
+	found, err := workouts.ListWorkouts(q)
+	if err != nil || len(found) == 0 {
+		return Workout{}, false
+	}
+	return found[0], true
+}
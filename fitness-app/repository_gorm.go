@@ -0,0 +1,629 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormRepository is the Postgres-backed Repository
+type gormRepository struct {
+	db     *gorm.DB
+	config Config
+}
+
+func newGormRepository(db *gorm.DB, config Config) *gormRepository {
+	return &gormRepository{db: withConfig(db, config), config: config}
+}
+
+// configKey carries the Config on gorm sessions for the Workout hooks,
+// which gorm calls with nothing but the session
+const configKey = "fitness:config"
+
+func withConfig(db *gorm.DB, config Config) *gorm.DB {
+	return db.Set(configKey, config).Session(&gorm.Session{})
+}
+
+// hookConfig is the Config withConfig put on the session, or the defaults
+// for a session opened without one
+func hookConfig(tx *gorm.DB) Config {
+	if c, ok := tx.Get(configKey); ok {
+		return c.(Config)
+	}
+	return defaultConfig()
+}
+
+// localTime is column as wall-clock time in the app's timezone (TZ), so
+// days and weeks grouped in SQL match dayKey's. A zone set by TZ has a name
+// Postgres knows; the system zone is only "Local" to Go, so its current UTC
+// offset stands in.
+func localTime(column string) clause.Expr {
+	if name := time.Local.String(); name != "Local" {
+		return gorm.Expr(column+" AT TIME ZONE ?", name)
+	}
+	_, offset := time.Now().Zone()
+	return gorm.Expr(column+" AT TIME ZONE ?::interval", fmt.Sprintf("%d seconds", offset))
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errNotFound
+	}
+	return err
+}
+
+func applyFilters(db *gorm.DB, conds []filterCond) *gorm.DB {
+	for _, f := range conds {
+		column := clause.Column{Name: f.Column}
+		var expr clause.Expression = clause.Eq{Column: column, Value: f.Value}
+		if values, ok := f.Value.([]string); ok {
+			in := clause.IN{Column: column}
+			for _, v := range values {
+				in.Values = append(in.Values, v)
+			}
+			expr = in
+		}
+		if f.Not {
+			expr = clause.Not(expr)
+		}
+		db = db.Where(expr)
+	}
+	return db
+}
+
+func (r *gormRepository) workoutQuery(q WorkoutQuery) *gorm.DB {
+	db := applyFilters(r.db.Model(&Workout{}), q.Filters)
+	if !q.From.IsZero() {
+		db = db.Where("created_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		db = db.Where("created_at <= ?", q.To)
+	}
+	if q.TopSets {
+		db = db.Where("set_type <> ? AND COALESCE(drop_set_id, 0) IN (0, id)", warmupSetType)
+	}
+	return db
+}
+
+func (r *gormRepository) CreateWorkout(w *Workout, drops []Workout) error {
+	w.DropSetID = 0
+	if len(drops) == 0 {
+		return r.db.Create(w).Error
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(w).Error; err != nil {
+			return err
+		}
+		w.DropSetID = w.ID
+		if err := tx.Model(w).UpdateColumn("drop_set_id", w.ID).Error; err != nil {
+			return err
+		}
+		for i := range drops {
+			drops[i].DropSetID = w.ID
+			if err := tx.Create(&drops[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *gormRepository) ImportWorkouts(ws []Workout) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ws).Error; err != nil {
+			return err
+		}
+		seen := map[string]bool{}
+		for _, w := range ws {
+			if seen[w.Exercise] {
+				continue
+			}
+			seen[w.Exercise] = true
+			if err := recomputePRs(tx, w.Exercise, r.config); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *gormRepository) GetWorkout(id uint) (Workout, error) {
+	var w Workout
+	err := r.db.First(&w, id).Error
+	return w, notFound(err)
+}
+
+func (r *gormRepository) ListWorkouts(q WorkoutQuery) ([]Workout, error) {
+	column := q.Sort.Column
+	if column == "" {
+		column = "created_at"
+	}
+	db := r.workoutQuery(q).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: q.Sort.Desc}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: q.Sort.Desc})
+	if q.Limit > 0 {
+		db = db.Limit(q.Limit)
+	}
+	var workouts []Workout
+	err := db.Find(&workouts).Error
+	return workouts, err
+}
+
+func (r *gormRepository) CountWorkouts(q WorkoutQuery) (int64, error) {
+	var n int64
+	err := r.workoutQuery(q).Count(&n).Error
+	return n, err
+}
+
+func (r *gormRepository) BestByReps(q WorkoutQuery) ([]Workout, error) {
+	var workouts []Workout
+	err := r.workoutQuery(q).Select("DISTINCT ON (reps) *").
+		Order("reps asc, " + loadSQL + " desc, created_at asc").Find(&workouts).Error
+	return workouts, err
+}
+
+func (r *gormRepository) WorkoutExercises() ([]string, error) {
+	var exercises []string
+	err := r.db.Model(&Workout{}).Distinct().Pluck("exercise", &exercises).Error
+	return exercises, err
+}
+
+func (r *gormRepository) WorkoutSummary(from, to time.Time) (workoutSummary, error) {
+	var s workoutSummary
+	err := r.db.Model(&Workout{}).Where("created_at >= ? AND created_at <= ?", from, to).Select(
+		"MIN(created_at) AS first_log, " +
+			"COALESCE(SUM(" + r.config.volumeSQL() + "), 0) AS volume, " +
+			"COUNT(*) AS sets").Scan(&s).Error
+	if err != nil {
+		return s, err
+	}
+	// A session is a day with sets or cardio
+	local := localTime("created_at")
+	days := r.db.Raw("SELECT DATE(?) AS day FROM workouts WHERE created_at >= ? AND created_at <= ? "+
+		"UNION SELECT DATE(?) FROM cardios WHERE created_at >= ? AND created_at <= ?", local, from, to, local, from, to)
+	err = r.db.Raw("SELECT COUNT(*) AS sessions, COUNT(DISTINCT date_trunc('week', day)) AS active_weeks FROM (?) AS days", days).
+		Row().Scan(&s.Sessions, &s.ActiveWeeks)
+	if err != nil || !from.IsZero() {
+		return s, err
+	}
+	var archives []ExerciseArchive
+	err = r.db.Find(&archives).Error
+	s.addArchives(archives)
+	return s, err
+}
+
+func (r *gormRepository) EachWorkoutBatch(size int, fn func([]Workout) error) error {
+	return eachByCreatedAt(r.db, size, func(w Workout) (time.Time, uint) { return w.CreatedAt, w.ID }, fn)
+}
+
+// eachByCreatedAt pages through a table oldest first, keyed on (created_at,
+// id). FindInBatches can't be used: it pages by id whatever the order, so
+// back-dated rows would fall between pages.
+func eachByCreatedAt[T any](db *gorm.DB, size int, key func(T) (time.Time, uint), fn func([]T) error) error {
+	var after *T
+	for {
+		var batch []T
+		q := db.Order("created_at asc, id asc").Limit(size)
+		if after != nil {
+			at, id := key(*after)
+			q = q.Where("(created_at, id) > (?, ?)", at, id)
+		}
+		if err := q.Find(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < size {
+			return nil
+		}
+		after = &batch[len(batch)-1]
+	}
+}
+
+func (r *gormRepository) WorkoutExportMeta() (exportMeta, error) {
+	return r.exportMeta("workouts")
+}
+
+// exportMeta aggregates in the database; only the summary comes back
+func (r *gormRepository) exportMeta(table string) (exportMeta, error) {
+	var a exportAggregates
+	err := r.db.Table(table).Select("COUNT(*) AS row_count, COALESCE(MAX(id), 0) AS max_id, COALESCE(SUM(id), 0) AS sum_id, " +
+		"MAX(created_at) AS latest_created_at, MAX(updated_at) AS latest_updated_at").Scan(&a).Error
+	return a.meta(), err
+}
+
+func (r *gormRepository) UpdateTags(tags map[uint]Tags) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for id, t := range tags {
+			if err := tx.Model(&Workout{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{"tags": t, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *gormRepository) UpdateMuscleGroups(groups map[uint]string) error {
+	if len(groups) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		ids := make([]uint, 0, len(groups))
+		for id, group := range groups {
+			if err := tx.Model(&Workout{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{"muscle_group": group, "updated_at": time.Now()}).Error; err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		// UpdateColumn skips the hooks, and the rollups are keyed by muscle group
+		var span struct{ From, To time.Time }
+		if err := tx.Model(&Workout{}).Where("id IN ?", ids).Select("min(created_at) AS \"from\", max(created_at) AS \"to\"").Scan(&span).Error; err != nil {
+			return err
+		}
+		return refreshWeeklySummaries(tx, span.From, span.To, r.config)
+	})
+}
+
+func (r *gormRepository) RecomputePRs(exercises []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, exercise := range exercises {
+			if err := recomputePRs(tx, exercise, r.config); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// findArchive is the exercise's pruned-history summary, zero if none
+func findArchive(tx *gorm.DB, exercise string) (ExerciseArchive, error) {
+	var archive ExerciseArchive
+	err := tx.Where("exercise = ?", exercise).Limit(1).Find(&archive).Error
+	return archive, err
+}
+
+func (r *gormRepository) PruneHistory(exercise string, keepSessions int) (int, error) {
+	var pruned []Workout
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Only sets before the oldest kept day are loaded; with none past the
+		// cap the comparison is against NULL and nothing comes back
+		local := localTime("created_at")
+		keepFrom := tx.Raw("SELECT DATE(?) AS day FROM workouts WHERE exercise = ? GROUP BY day ORDER BY day DESC OFFSET ? LIMIT 1",
+			local, exercise, keepSessions-1)
+		if err := tx.Where("exercise = ? AND ? < (?)", exercise, local, keepFrom).Order("created_at asc, id asc").Find(&pruned).Error; err != nil {
+			return err
+		}
+		if len(pruned) == 0 {
+			return nil
+		}
+		archive, err := findArchive(tx, exercise)
+		if err != nil {
+			return err
+		}
+		archive.Exercise = exercise
+		archive.add(pruned, r.config)
+
+		if err := tx.Where("exercise = ? AND ? < (?)", exercise, local, keepFrom).Delete(&Workout{}).Error; err != nil {
+			return err
+		}
+		days, weeks := prunedPeriods(pruned)
+		for _, p := range []struct {
+			starts []time.Time
+			length int
+			count  *int64
+		}{{days, 1, &archive.EmptiedDays}, {weeks, 7, &archive.EmptiedWeeks}} {
+			for _, start := range p.starts {
+				var left int64
+				if err := tx.Model(&Workout{}).Where("created_at >= ? AND created_at < ?", start, start.AddDate(0, 0, p.length)).Count(&left).Error; err != nil {
+					return err
+				}
+				if left == 0 {
+					*p.count++
+				}
+			}
+		}
+		if err := tx.Save(&archive).Error; err != nil {
+			return err
+		}
+		if err := archiveWeeks(tx, pruned, r.config); err != nil {
+			return err
+		}
+		return rebuildExerciseStat(tx, exercise)
+	})
+	return len(pruned), err
+}
+
+func (r *gormRepository) FindExerciseStat(exercise string) (ExerciseStat, error) {
+	var stat ExerciseStat
+	err := r.db.Where("exercise = ?", exercise).First(&stat).Error
+	return stat, notFound(err)
+}
+
+func (r *gormRepository) RebuildExerciseStats() (int, error) {
+	var exercises []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&ExerciseStat{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Workout{}).Distinct().Pluck("exercise", &exercises).Error; err != nil {
+			return err
+		}
+		for _, exercise := range exercises {
+			if err := rebuildExerciseStat(tx, exercise); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(exercises), err
+}
+
+func (r *gormRepository) ListWeeklySummaries(from, to time.Time) ([]WeeklySummary, error) {
+	var weeks []WeeklySummary
+	err := r.db.Where("start >= ? AND start <= ?", from, to).Order("start asc, exercise asc").Find(&weeks).Error
+	return weeks, err
+}
+
+func (r *gormRepository) RebuildWeeklySummaries() (int, []summaryDrift, error) {
+	var rebuilt map[string]WeeklySummary
+	var drifts []summaryDrift
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		rebuilt, drifts, err = rebuildRollups(tx, r.config)
+		return err
+	})
+	return countWeeks(rebuilt), drifts, err
+}
+
+func (r *gormRepository) FindExerciseConfig(exercise string) (ExerciseConfig, error) {
+	var cfg ExerciseConfig
+	err := r.db.Where("exercise = ?", exercise).First(&cfg).Error
+	return cfg, notFound(err)
+}
+
+func (r *gormRepository) ListExerciseConfigs() ([]ExerciseConfig, error) {
+	var configs []ExerciseConfig
+	err := r.db.Order("exercise asc").Find(&configs).Error
+	return configs, err
+}
+
+func (r *gormRepository) SaveExerciseConfig(cfg *ExerciseConfig) error {
+	return r.db.Save(cfg).Error
+}
+
+func (r *gormRepository) DeleteExerciseConfig(exercise string) (bool, error) {
+	result := r.db.Where("exercise = ?", exercise).Delete(&ExerciseConfig{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormRepository) SeedExerciseConfigs(configs []ExerciseConfig) (int64, error) {
+	var count int64
+	if err := r.db.Model(&ExerciseConfig{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, nil
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&configs)
+	return result.RowsAffected, result.Error
+}
+
+func (r *gormRepository) CurrentTrainingMax(lift string) (TrainingMax, error) {
+	var tm TrainingMax
+	err := r.db.Where("lift = ?", lift).Order("created_at desc, id desc").First(&tm).Error
+	return tm, notFound(err)
+}
+
+func (r *gormRepository) ListTrainingMaxes() ([]TrainingMax, error) {
+	var maxes []TrainingMax
+	err := r.db.Select("DISTINCT ON (lift) *").Order("lift asc, created_at desc, id desc").Find(&maxes).Error
+	return maxes, err
+}
+
+func (r *gormRepository) CreateTrainingMax(tm *TrainingMax) error {
+	return r.db.Create(tm).Error
+}
+
+func (r *gormRepository) Onboard(o *onboarding) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&o.Profile).Error; err != nil {
+			return err
+		}
+		if o.Metrics != nil {
+			if err := tx.Create(o.Metrics).Error; err != nil {
+				return err
+			}
+		}
+		for i := range o.ExerciseConfigs {
+			cfg := &o.ExerciseConfigs[i]
+			var existing ExerciseConfig
+			err := tx.Where("exercise = ?", cfg.Exercise).First(&existing).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Create(cfg).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			existing.StartingWeight, existing.StartingReps = cfg.StartingWeight, cfg.StartingReps
+			if err := tx.Save(&existing).Error; err != nil {
+				return err
+			}
+			*cfg = existing
+		}
+		if len(o.TrainingMaxes) > 0 {
+			if err := tx.Create(&o.TrainingMaxes).Error; err != nil {
+				return err
+			}
+		}
+		for i := range o.Goals {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "exercise"}},
+				DoUpdates: clause.AssignmentColumns([]string{"target_one_rm", "updated_at"}),
+			}).Create(&o.Goals[i]).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *gormRepository) FindGoal(exercise string) (Goal, error) {
+	var g Goal
+	err := r.db.Where("exercise = ?", exercise).First(&g).Error
+	return g, notFound(err)
+}
+
+func (r *gormRepository) ListGoals() ([]Goal, error) {
+	var goals []Goal
+	err := r.db.Order("exercise asc").Find(&goals).Error
+	return goals, err
+}
+
+func (r *gormRepository) SaveGoal(g *Goal) error {
+	return r.db.Save(g).Error
+}
+
+func (r *gormRepository) DeleteGoal(exercise string) (bool, error) {
+	result := r.db.Where("exercise = ?", exercise).Delete(&Goal{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormRepository) FindSessionName(date string) (SessionName, error) {
+	var n SessionName
+	err := r.db.Where("date = ?", date).First(&n).Error
+	return n, notFound(err)
+}
+
+func (r *gormRepository) ListSessionNames() ([]SessionName, error) {
+	var names []SessionName
+	err := r.db.Order("date asc").Find(&names).Error
+	return names, err
+}
+
+func (r *gormRepository) SaveSessionName(n *SessionName) error {
+	return r.db.Save(n).Error
+}
+
+func (r *gormRepository) DeleteSessionName(date string) (bool, error) {
+	result := r.db.Where("date = ?", date).Delete(&SessionName{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormRepository) CreateMetrics(m *BodyMetrics) error {
+	return r.db.Create(m).Error
+}
+
+func (r *gormRepository) ListMetrics() ([]BodyMetrics, error) {
+	var metrics []BodyMetrics
+	err := r.db.Order("created_at asc").Find(&metrics).Error
+	return metrics, err
+}
+
+func (r *gormRepository) LastMetrics() (BodyMetrics, error) {
+	var m BodyMetrics
+	err := r.db.Order("created_at desc").First(&m).Error
+	return m, notFound(err)
+}
+
+func (r *gormRepository) GetMetrics(id uint) (BodyMetrics, error) {
+	var m BodyMetrics
+	err := r.db.First(&m, id).Error
+	return m, notFound(err)
+}
+
+func (r *gormRepository) SaveMetrics(m *BodyMetrics) error {
+	return r.db.Save(m).Error
+}
+
+func (r *gormRepository) EachMetricsBatch(size int, fn func([]BodyMetrics) error) error {
+	return eachByCreatedAt(r.db, size, func(m BodyMetrics) (time.Time, uint) { return m.CreatedAt, m.ID }, fn)
+}
+
+func (r *gormRepository) MetricsExportMeta() (exportMeta, error) {
+	return r.exportMeta("body_metrics")
+}
+
+func (r *gormRepository) CreateCardio(entry *Cardio) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *gormRepository) GetCardio(id uint) (Cardio, error) {
+	var entry Cardio
+	err := r.db.First(&entry, id).Error
+	return entry, notFound(err)
+}
+
+func (r *gormRepository) ListCardio() ([]Cardio, error) {
+	var entries []Cardio
+	err := r.db.Order("created_at desc, id desc").Find(&entries).Error
+	return entries, err
+}
+
+func (r *gormRepository) SaveCardio(entry *Cardio) error {
+	return r.db.Save(entry).Error
+}
+
+func (r *gormRepository) DeleteCardio(id uint) (bool, error) {
+	result := r.db.Delete(&Cardio{}, id)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (r *gormRepository) Tables() ([]string, error) {
+	names := make([]string, 0, len(models))
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: r.db}
+		if err := stmt.Parse(m); err != nil {
+			return nil, err
+		}
+		names = append(names, stmt.Schema.Table)
+	}
+	return names, nil
+}
+
+func (r *gormRepository) Reset(ctx context.Context) error {
+	tables, err := r.Tables()
+	if err != nil {
+		return err
+	}
+	quoted := make([]string, len(tables))
+	for i, t := range tables {
+		quoted[i] = `"` + t + `"`
+	}
+	return r.db.WithContext(ctx).Exec("TRUNCATE " + strings.Join(quoted, ", ") + " RESTART IDENTITY").Error
+}
+
+// Analyze runs on the raw sql.DB since VACUUM can't run inside a transaction.
+// Table names come from our own models, never from the request.
+func (r *gormRepository) Analyze(ctx context.Context, table string, vacuum bool) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	command := "ANALYZE"
+	if vacuum {
+		command = "VACUUM ANALYZE"
+	}
+	_, err = sqlDB.ExecContext(ctx, command+` "`+table+`"`)
+	return err
+}
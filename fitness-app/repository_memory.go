@@ -0,0 +1,963 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryRepository keeps everything in process memory behind one lock.
+// Nothing survives a restart; it exists so the app can run without Postgres.
+// Derived data the GORM hooks maintain (exercise stats) is updated inline.
+type memoryRepository struct {
+	mu       sync.RWMutex
+	workouts []Workout
+	metrics  []BodyMetrics
+	cardio   []Cardio
+	configs  []ExerciseConfig
+	stats    map[string]ExerciseStat
+	archives map[string]ExerciseArchive
+	weeks    map[string]WeeklySummary
+	pruned   map[string]WeeklySummary // WeeklyArchive
+	maxes    []TrainingMax
+	goals    []Goal
+	sessions []SessionName
+	profile  *Profile
+	lastID   map[string]uint
+	config   Config
+}
+
+func newMemoryRepository(config Config) *memoryRepository {
+	return &memoryRepository{config: config, stats: map[string]ExerciseStat{}, archives: map[string]ExerciseArchive{}, weeks: map[string]WeeklySummary{}, pruned: map[string]WeeklySummary{}, lastID: map[string]uint{}}
+}
+
+// rebuildWeeks recomputes the weekly rollups from the sets and the pruned
+// archive
+func (r *memoryRepository) rebuildWeeks() {
+	r.weeks = r.config.summarizeWeeks(r.workouts)
+	for _, s := range r.pruned {
+		addRollup(r.weeks, s)
+	}
+}
+
+func (r *memoryRepository) nextID(table string) uint {
+	r.lastID[table]++
+	return r.lastID[table]
+}
+
+// Rows are copied in and out so callers can't mutate the store
+func cloneWorkout(w Workout) Workout {
+	w.Tags = append(Tags{}, w.Tags...)
+	return w
+}
+
+func (f filterCond) matches(v interface{}) bool {
+	if values, ok := f.Value.([]string); ok {
+		for _, want := range values {
+			if v == want {
+				return true
+			}
+		}
+		return false
+	}
+	return v == f.Value
+}
+
+// workoutColumn reads the field behind a filter or sort column
+func workoutColumn(w Workout, column string) interface{} {
+	switch column {
+	case "exercise":
+		return w.Exercise
+	case "variation":
+		return w.Variation
+	case "muscle_group":
+		return w.MuscleGroup
+	case "equipment":
+		return w.Equipment
+	case "set_type":
+		return w.SetType
+	case "is_failure":
+		return bool(w.IsFailure)
+	case "is_amrap":
+		return bool(w.IsAMRAP)
+	case "is_pr":
+		return w.IsPR
+	case "weight":
+		return float64(w.Weight)
+	case "reps":
+		return w.Reps
+	case "id":
+		return w.ID
+	}
+	return w.CreatedAt
+}
+
+func columnLess(a, b interface{}) bool {
+	switch a := a.(type) {
+	case string:
+		return a < b.(string)
+	case float64:
+		return a < b.(float64)
+	case int:
+		return a < b.(int)
+	case uint:
+		return a < b.(uint)
+	case time.Time:
+		return a.Before(b.(time.Time))
+	}
+	return false
+}
+
+func (q WorkoutQuery) matches(w Workout) bool {
+	for _, f := range q.Filters {
+		if f.matches(workoutColumn(w, f.Column)) == f.Not {
+			return false
+		}
+	}
+	if !q.From.IsZero() && w.CreatedAt.Before(q.From) {
+		return false
+	}
+	if q.TopSets && (!countsForPR(w) || isDropFollower(w)) {
+		return false
+	}
+	return q.To.IsZero() || !w.CreatedAt.After(q.To)
+}
+
+func (r *memoryRepository) CreateWorkout(w *Workout, drops []Workout) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	insert := func(w *Workout) {
+		w.ID = r.nextID("workouts")
+		if w.CreatedAt.IsZero() {
+			w.CreatedAt = now
+		}
+		w.UpdatedAt = now
+		if w.Tags == nil {
+			w.Tags = Tags{}
+		}
+		r.workouts = append(r.workouts, cloneWorkout(*w))
+		r.updateStat(*w)
+		r.config.addToWeek(r.weeks, *w)
+	}
+
+	w.DropSetID = 0
+	insert(w)
+	if len(drops) > 0 {
+		w.DropSetID = w.ID
+		r.workouts[len(r.workouts)-1].DropSetID = w.ID
+	}
+	for i := range drops {
+		drops[i].DropSetID = w.ID
+		insert(&drops[i])
+	}
+	return nil
+}
+
+func (r *memoryRepository) ImportWorkouts(ws []Workout) error {
+	r.mu.Lock()
+	var exercises []string
+	seen := map[string]bool{}
+	now := time.Now()
+	for i := range ws {
+		w := &ws[i]
+		w.ID = r.nextID("workouts")
+		if w.UpdatedAt.IsZero() {
+			w.UpdatedAt = now
+		}
+		if w.Tags == nil {
+			w.Tags = Tags{}
+		}
+		r.workouts = append(r.workouts, cloneWorkout(*w))
+		r.updateStat(*w)
+		r.config.addToWeek(r.weeks, *w)
+		if !seen[w.Exercise] {
+			seen[w.Exercise] = true
+			exercises = append(exercises, w.Exercise)
+		}
+	}
+	r.mu.Unlock()
+	return r.RecomputePRs(exercises)
+}
+
+// updateStat mirrors Workout.AfterCreate: a new set can only raise the best
+func (r *memoryRepository) updateStat(w Workout) {
+	if !countsForPR(w) {
+		return
+	}
+	stat := statFromWorkout(w)
+	if existing, ok := r.stats[w.Exercise]; ok && existing.BestOneRM >= stat.BestOneRM {
+		return
+	}
+	stat.UpdatedAt = time.Now()
+	r.stats[w.Exercise] = stat
+}
+
+func (r *memoryRepository) GetWorkout(id uint) (Workout, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, w := range r.workouts {
+		if w.ID == id {
+			return cloneWorkout(w), nil
+		}
+	}
+	return Workout{}, errNotFound
+}
+
+func (r *memoryRepository) ListWorkouts(q WorkoutQuery) ([]Workout, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	workouts := []Workout{}
+	for _, w := range r.workouts {
+		if q.matches(w) {
+			workouts = append(workouts, cloneWorkout(w))
+		}
+	}
+	column := q.Sort.Column
+	if column == "" {
+		column = "created_at"
+	}
+	sort.SliceStable(workouts, func(i, j int) bool {
+		a, b := workoutColumn(workouts[i], column), workoutColumn(workouts[j], column)
+		if a == b {
+			a, b = workouts[i].ID, workouts[j].ID
+		}
+		if q.Sort.Desc {
+			return columnLess(b, a)
+		}
+		return columnLess(a, b)
+	})
+	if q.Limit > 0 && len(workouts) > q.Limit {
+		workouts = workouts[:q.Limit]
+	}
+	return workouts, nil
+}
+
+func (r *memoryRepository) BestByReps(q WorkoutQuery) ([]Workout, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	best := map[int]Workout{}
+	for _, w := range r.workouts {
+		if !q.matches(w) {
+			continue
+		}
+		b, ok := best[w.Reps]
+		if !ok || totalLoad(w) > totalLoad(b) || (totalLoad(w) == totalLoad(b) && w.CreatedAt.Before(b.CreatedAt)) {
+			best[w.Reps] = w
+		}
+	}
+	workouts := make([]Workout, 0, len(best))
+	for _, w := range best {
+		workouts = append(workouts, cloneWorkout(w))
+	}
+	sort.Slice(workouts, func(i, j int) bool { return workouts[i].Reps < workouts[j].Reps })
+	return workouts, nil
+}
+
+func (r *memoryRepository) CountWorkouts(q WorkoutQuery) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var n int64
+	for _, w := range r.workouts {
+		if q.matches(w) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memoryRepository) WorkoutExercises() ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := map[string]bool{}
+	exercises := []string{}
+	for _, w := range r.workouts {
+		if !seen[w.Exercise] {
+			seen[w.Exercise] = true
+			exercises = append(exercises, w.Exercise)
+		}
+	}
+	return exercises, nil
+}
+
+func (r *memoryRepository) WorkoutSummary(from, to time.Time) (workoutSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var s workoutSummary
+	days, weeks := map[string]bool{}, map[string]bool{}
+	for _, w := range r.workouts {
+		if w.CreatedAt.Before(from) || w.CreatedAt.After(to) {
+			continue
+		}
+		days[dayKey(w.CreatedAt)] = true
+		weeks[dayKey(startOfWeek(w.CreatedAt.In(time.Local)))] = true
+		if s.FirstLog == nil || w.CreatedAt.Before(*s.FirstLog) {
+			first := w.CreatedAt
+			s.FirstLog = &first
+		}
+		s.Volume += r.config.workoutVolume(w)
+		s.Sets++
+	}
+	for _, e := range r.cardio {
+		if !e.CreatedAt.Before(from) && !e.CreatedAt.After(to) {
+			days[dayKey(e.CreatedAt)] = true
+			weeks[dayKey(startOfWeek(e.CreatedAt.In(time.Local)))] = true
+		}
+	}
+	s.Sessions, s.ActiveWeeks = int64(len(days)), int64(len(weeks))
+	if !from.IsZero() {
+		return s, nil
+	}
+	archives := make([]ExerciseArchive, 0, len(r.archives))
+	for _, a := range r.archives {
+		archives = append(archives, a)
+	}
+	s.addArchives(archives)
+	return s, nil
+}
+
+func (r *memoryRepository) EachWorkoutBatch(size int, fn func([]Workout) error) error {
+	workouts, _ := r.ListWorkouts(WorkoutQuery{})
+	for start := 0; start < len(workouts); start += size {
+		end := min(start+size, len(workouts))
+		if err := fn(workouts[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) WorkoutExportMeta() (exportMeta, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return memoryExportMeta(r.workouts, func(w Workout) (uint, time.Time, time.Time) { return w.ID, w.CreatedAt, w.UpdatedAt }), nil
+}
+
+// memoryExportMeta computes the same aggregates as the GORM repository
+func memoryExportMeta[T any](rows []T, key func(T) (uint, time.Time, time.Time)) exportMeta {
+	a := exportAggregates{Rows: int64(len(rows))}
+	for _, row := range rows {
+		id, created, updated := key(row)
+		a.MaxID = max(a.MaxID, id)
+		a.SumID += int64(id)
+		if a.LatestCreatedAt == nil || created.After(*a.LatestCreatedAt) {
+			a.LatestCreatedAt = &created
+		}
+		if !updated.IsZero() && (a.LatestUpdatedAt == nil || updated.After(*a.LatestUpdatedAt)) {
+			a.LatestUpdatedAt = &updated
+		}
+	}
+	return a.meta()
+}
+
+func (r *memoryRepository) UpdateTags(tags map[uint]Tags) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, w := range r.workouts {
+		if t, ok := tags[w.ID]; ok {
+			r.workouts[i].Tags = append(Tags{}, t...)
+			r.workouts[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) UpdateMuscleGroups(groups map[uint]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, w := range r.workouts {
+		if group, ok := groups[w.ID]; ok {
+			r.workouts[i].MuscleGroup = group
+			r.workouts[i].UpdatedAt = time.Now()
+		}
+	}
+	r.rebuildWeeks()
+	return nil
+}
+
+func (r *memoryRepository) RecomputePRs(exercises []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, exercise := range exercises {
+		var sets []Workout
+		for _, w := range r.workouts {
+			if w.Exercise == exercise {
+				sets = append(sets, w)
+			}
+		}
+		sort.SliceStable(sets, func(i, j int) bool { return sets[i].CreatedAt.Before(sets[j].CreatedAt) })
+		prs := map[uint]bool{}
+		for _, id := range prIDs(sets, r.archives[exercise].BestOneRM) {
+			prs[id] = true
+		}
+		for i, w := range r.workouts {
+			if w.Exercise == exercise {
+				r.workouts[i].IsPR = prs[w.ID]
+			}
+		}
+	}
+	r.rebuildWeeks()
+	return nil
+}
+
+func (r *memoryRepository) FindExerciseStat(exercise string) (ExerciseStat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stat, ok := r.stats[exercise]
+	if !ok {
+		return ExerciseStat{}, errNotFound
+	}
+	return stat, nil
+}
+
+func (r *memoryRepository) RebuildExerciseStats() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats = map[string]ExerciseStat{}
+	for _, w := range r.workouts {
+		r.updateStat(w)
+	}
+	for exercise := range r.archives {
+		r.applyArchive(exercise)
+	}
+	return len(r.stats), nil
+}
+
+// applyArchive lets a pruned best stand when no stored set beats it
+func (r *memoryRepository) applyArchive(exercise string) {
+	archive, ok := r.archives[exercise]
+	if !ok {
+		return
+	}
+	if stat, ok := r.stats[exercise]; !ok || archive.BestOneRM > stat.BestOneRM {
+		stat = archive.stat()
+		stat.UpdatedAt = time.Now()
+		r.stats[exercise] = stat
+	}
+}
+
+func (r *memoryRepository) PruneHistory(exercise string, keepSessions int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sets []Workout
+	for _, w := range r.workouts {
+		if w.Exercise == exercise {
+			sets = append(sets, w)
+		}
+	}
+	sort.SliceStable(sets, func(i, j int) bool { return sets[i].CreatedAt.Before(sets[j].CreatedAt) })
+	pruned := prunedSets(sets, keepSessions)
+	if len(pruned) == 0 {
+		return 0, nil
+	}
+
+	gone := make(map[uint]bool, len(pruned))
+	for _, w := range pruned {
+		gone[w.ID] = true
+	}
+	kept := make([]Workout, 0, len(r.workouts)-len(pruned))
+	for _, w := range r.workouts {
+		if !gone[w.ID] {
+			kept = append(kept, w)
+		}
+	}
+	r.workouts = kept
+	for _, w := range pruned {
+		r.config.addToWeek(r.pruned, w)
+	}
+
+	archive := r.archives[exercise]
+	archive.Exercise = exercise
+	archive.add(pruned, r.config)
+	days, weeks := prunedPeriods(pruned)
+	for _, day := range days {
+		if !r.anyWorkoutBetween(day, day.AddDate(0, 0, 1)) {
+			archive.EmptiedDays++
+		}
+	}
+	for _, week := range weeks {
+		if !r.anyWorkoutBetween(week, week.AddDate(0, 0, 7)) {
+			archive.EmptiedWeeks++
+		}
+	}
+	archive.UpdatedAt = time.Now()
+	r.archives[exercise] = archive
+
+	// Same as rebuildExerciseStat: the best may have been pruned
+	delete(r.stats, exercise)
+	for _, w := range r.workouts {
+		if w.Exercise == exercise {
+			r.updateStat(w)
+		}
+	}
+	r.applyArchive(exercise)
+	return len(pruned), nil
+}
+
+func (r *memoryRepository) anyWorkoutBetween(from, to time.Time) bool {
+	for _, w := range r.workouts {
+		if !w.CreatedAt.Before(from) && w.CreatedAt.Before(to) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryRepository) ListWeeklySummaries(from, to time.Time) ([]WeeklySummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	weeks := []WeeklySummary{}
+	for _, s := range r.weeks {
+		if !s.Start.Before(from) && !s.Start.After(to) {
+			weeks = append(weeks, s)
+		}
+	}
+	sort.Slice(weeks, func(i, j int) bool {
+		if !weeks[i].Start.Equal(weeks[j].Start) {
+			return weeks[i].Start.Before(weeks[j].Start)
+		}
+		return weeks[i].Exercise < weeks[j].Exercise
+	})
+	return weeks, nil
+}
+
+func (r *memoryRepository) RebuildWeeklySummaries() (int, []summaryDrift, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := make([]WeeklySummary, 0, len(r.weeks))
+	for _, s := range r.weeks {
+		stored = append(stored, s)
+	}
+	r.rebuildWeeks()
+	return countWeeks(r.weeks), summaryDrifts(stored, r.weeks), nil
+}
+
+func (r *memoryRepository) FindExerciseConfig(exercise string) (ExerciseConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, cfg := range r.configs {
+		if cfg.Exercise == exercise {
+			return cfg, nil
+		}
+	}
+	return ExerciseConfig{}, errNotFound
+}
+
+func (r *memoryRepository) ListExerciseConfigs() ([]ExerciseConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	configs := append([]ExerciseConfig{}, r.configs...)
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Exercise < configs[j].Exercise })
+	return configs, nil
+}
+
+func (r *memoryRepository) SaveExerciseConfig(cfg *ExerciseConfig) error {
+	if err := cfg.validateColor(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	cfg.UpdatedAt = now
+	for i, existing := range r.configs {
+		if existing.ID == cfg.ID && cfg.ID != 0 {
+			r.configs[i] = *cfg
+			return nil
+		}
+	}
+	cfg.ID = r.nextID("exercise_configs")
+	if cfg.CreatedAt.IsZero() {
+		cfg.CreatedAt = now
+	}
+	r.configs = append(r.configs, *cfg)
+	return nil
+}
+
+func (r *memoryRepository) DeleteExerciseConfig(exercise string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, cfg := range r.configs {
+		if cfg.Exercise == exercise {
+			r.configs = append(r.configs[:i], r.configs[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryRepository) SeedExerciseConfigs(configs []ExerciseConfig) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.configs) > 0 {
+		return 0, nil
+	}
+	for i := range configs {
+		if err := configs[i].validateColor(); err != nil {
+			return 0, err
+		}
+	}
+	var n int64
+	now := time.Now()
+	seen := map[string]bool{}
+	for _, cfg := range configs {
+		if seen[cfg.Exercise] {
+			continue // Same as ON CONFLICT DO NOTHING on the unique index
+		}
+		seen[cfg.Exercise] = true
+		cfg.ID, cfg.CreatedAt, cfg.UpdatedAt = r.nextID("exercise_configs"), now, now
+		r.configs = append(r.configs, cfg)
+		n++
+	}
+	return n, nil
+}
+
+func (r *memoryRepository) CurrentTrainingMax(lift string) (TrainingMax, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	// Appended in time order, so the last match is current
+	for i := len(r.maxes) - 1; i >= 0; i-- {
+		if r.maxes[i].Lift == lift {
+			return r.maxes[i], nil
+		}
+	}
+	return TrainingMax{}, errNotFound
+}
+
+func (r *memoryRepository) ListTrainingMaxes() ([]TrainingMax, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	current := map[string]TrainingMax{}
+	for _, tm := range r.maxes {
+		current[tm.Lift] = tm
+	}
+	maxes := make([]TrainingMax, 0, len(current))
+	for _, tm := range current {
+		maxes = append(maxes, tm)
+	}
+	sort.Slice(maxes, func(i, j int) bool { return maxes[i].Lift < maxes[j].Lift })
+	return maxes, nil
+}
+
+func (r *memoryRepository) CreateTrainingMax(tm *TrainingMax) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tm.ID = r.nextID("training_maxes")
+	if tm.CreatedAt.IsZero() {
+		tm.CreatedAt = time.Now()
+	}
+	r.maxes = append(r.maxes, *tm)
+	return nil
+}
+
+func (r *memoryRepository) Onboard(o *onboarding) error {
+	for i := range o.ExerciseConfigs {
+		if err := o.ExerciseConfigs[i].validateColor(); err != nil {
+			return err
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Nothing below can fail, so writing under the lock is all-or-nothing
+	now := time.Now()
+	if r.profile == nil {
+		o.Profile.CreatedAt = now
+	} else {
+		o.Profile.CreatedAt = r.profile.CreatedAt
+	}
+	o.Profile.UpdatedAt = now
+	p := o.Profile
+	r.profile = &p
+
+	if o.Metrics != nil {
+		o.Metrics.ID, o.Metrics.CreatedAt = r.nextID("body_metrics"), now
+		r.metrics = append(r.metrics, *o.Metrics)
+	}
+	for i := range o.ExerciseConfigs {
+		cfg := &o.ExerciseConfigs[i]
+		existing := -1
+		for j := range r.configs {
+			if r.configs[j].Exercise == cfg.Exercise {
+				existing = j
+			}
+		}
+		if existing >= 0 {
+			r.configs[existing].StartingWeight, r.configs[existing].StartingReps = cfg.StartingWeight, cfg.StartingReps
+			r.configs[existing].UpdatedAt = now
+			*cfg = r.configs[existing]
+			continue
+		}
+		cfg.ID, cfg.CreatedAt, cfg.UpdatedAt = r.nextID("exercise_configs"), now, now
+		r.configs = append(r.configs, *cfg)
+	}
+	for i := range o.TrainingMaxes {
+		tm := &o.TrainingMaxes[i]
+		tm.ID, tm.CreatedAt = r.nextID("training_maxes"), now
+		r.maxes = append(r.maxes, *tm)
+	}
+	for i := range o.Goals {
+		g := &o.Goals[i]
+		g.CreatedAt, g.UpdatedAt = now, now
+		replaced := false
+		for j := range r.goals {
+			if r.goals[j].Exercise == g.Exercise {
+				g.ID, g.CreatedAt = r.goals[j].ID, r.goals[j].CreatedAt
+				r.goals[j], replaced = *g, true
+			}
+		}
+		if !replaced {
+			g.ID = r.nextID("goals")
+			r.goals = append(r.goals, *g)
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) FindGoal(exercise string) (Goal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, g := range r.goals {
+		if g.Exercise == exercise {
+			return g, nil
+		}
+	}
+	return Goal{}, errNotFound
+}
+
+func (r *memoryRepository) ListGoals() ([]Goal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	goals := append([]Goal{}, r.goals...)
+	sort.Slice(goals, func(i, j int) bool { return goals[i].Exercise < goals[j].Exercise })
+	return goals, nil
+}
+
+func (r *memoryRepository) SaveGoal(g *Goal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	g.UpdatedAt = now
+	for i, existing := range r.goals {
+		if existing.ID == g.ID && g.ID != 0 {
+			r.goals[i] = *g
+			return nil
+		}
+	}
+	g.ID = r.nextID("goals")
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = now
+	}
+	r.goals = append(r.goals, *g)
+	return nil
+}
+
+func (r *memoryRepository) DeleteGoal(exercise string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, g := range r.goals {
+		if g.Exercise == exercise {
+			r.goals = append(r.goals[:i], r.goals[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryRepository) FindSessionName(date string) (SessionName, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, n := range r.sessions {
+		if n.Date == date {
+			return n, nil
+		}
+	}
+	return SessionName{}, errNotFound
+}
+
+func (r *memoryRepository) ListSessionNames() ([]SessionName, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := append([]SessionName{}, r.sessions...)
+	sort.Slice(names, func(i, j int) bool { return names[i].Date < names[j].Date })
+	return names, nil
+}
+
+func (r *memoryRepository) SaveSessionName(n *SessionName) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	n.UpdatedAt = now
+	for i, existing := range r.sessions {
+		if existing.ID == n.ID && n.ID != 0 {
+			r.sessions[i] = *n
+			return nil
+		}
+	}
+	n.ID = r.nextID("session_names")
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = now
+	}
+	r.sessions = append(r.sessions, *n)
+	return nil
+}
+
+func (r *memoryRepository) DeleteSessionName(date string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, n := range r.sessions {
+		if n.Date == date {
+			r.sessions = append(r.sessions[:i], r.sessions[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryRepository) CreateMetrics(m *BodyMetrics) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m.ID = r.nextID("body_metrics")
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+	m.UpdatedAt = time.Now()
+	r.metrics = append(r.metrics, *m)
+	return nil
+}
+
+func (r *memoryRepository) GetMetrics(id uint) (BodyMetrics, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, m := range r.metrics {
+		if m.ID == id {
+			return m, nil
+		}
+	}
+	return BodyMetrics{}, errNotFound
+}
+
+func (r *memoryRepository) SaveMetrics(m *BodyMetrics) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.metrics {
+		if existing.ID == m.ID {
+			m.UpdatedAt = time.Now()
+			r.metrics[i] = *m
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (r *memoryRepository) ListMetrics() ([]BodyMetrics, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	metrics := append([]BodyMetrics{}, r.metrics...)
+	sort.SliceStable(metrics, func(i, j int) bool { return metrics[i].CreatedAt.Before(metrics[j].CreatedAt) })
+	return metrics, nil
+}
+
+func (r *memoryRepository) LastMetrics() (BodyMetrics, error) {
+	metrics, _ := r.ListMetrics()
+	if len(metrics) == 0 {
+		return BodyMetrics{}, errNotFound
+	}
+	return metrics[len(metrics)-1], nil
+}
+
+func (r *memoryRepository) EachMetricsBatch(size int, fn func([]BodyMetrics) error) error {
+	metrics, _ := r.ListMetrics()
+	for start := 0; start < len(metrics); start += size {
+		end := min(start+size, len(metrics))
+		if err := fn(metrics[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) MetricsExportMeta() (exportMeta, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return memoryExportMeta(r.metrics, func(m BodyMetrics) (uint, time.Time, time.Time) { return m.ID, m.CreatedAt, m.UpdatedAt }), nil
+}
+
+func (r *memoryRepository) CreateCardio(entry *Cardio) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.ID = r.nextID("cardios")
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	r.cardio = append(r.cardio, *entry)
+	return nil
+}
+
+func (r *memoryRepository) GetCardio(id uint) (Cardio, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, entry := range r.cardio {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+	return Cardio{}, errNotFound
+}
+
+func (r *memoryRepository) ListCardio() ([]Cardio, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := append([]Cardio{}, r.cardio...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].ID > entries[j].ID
+		}
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+func (r *memoryRepository) SaveCardio(entry *Cardio) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.cardio {
+		if existing.ID == entry.ID {
+			r.cardio[i] = *entry
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (r *memoryRepository) DeleteCardio(id uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, entry := range r.cardio {
+		if entry.ID == id {
+			r.cardio = append(r.cardio[:i], r.cardio[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryRepository) Ping(context.Context) error {
+	return nil
+}
+
+func (r *memoryRepository) Tables() ([]string, error) {
+	return nil, nil
+}
+
+func (r *memoryRepository) Analyze(context.Context, string, bool) error {
+	return nil
+}
+
+func (r *memoryRepository) Reset(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workouts, r.metrics, r.cardio, r.configs, r.maxes, r.goals, r.sessions, r.profile = nil, nil, nil, nil, nil, nil, nil, nil
+	r.stats, r.archives, r.weeks, r.lastID = map[string]ExerciseStat{}, map[string]ExerciseArchive{}, map[string]WeeklySummary{}, map[string]uint{}
+	r.pruned = map[string]WeeklySummary{}
+	return nil
+}
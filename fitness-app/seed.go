@@ -0,0 +1,53 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+)
+
+//go:embed starter_exercises.json
+var starterExercisesJSON []byte
+
+// starterExercises loads the built-in list, or the file at
+// EXERCISE_SEED_FILE when set.
+func starterExercises(seedFile string) ([]ExerciseConfig, error) {
+	data := starterExercisesJSON
+	if seedFile != "" {
+		var err error
+		if data, err = os.ReadFile(seedFile); err != nil {
+			return nil, fmt.Errorf("reading EXERCISE_SEED_FILE: %w", err)
+		}
+	}
+	var configs []ExerciseConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("parsing exercise seed: %w", err)
+	}
+	return configs, nil
+}
+
+// seedExerciseConfigs fills an empty exercise_configs table with starter
+// defaults. It never touches existing rows, so it's safe on every boot.
+func seedExerciseConfigs(repo Repository, seedFile string) (int64, error) {
+	configs, err := starterExercises(seedFile)
+	if err != nil {
+		return 0, err
+	}
+	return repo.SeedExerciseConfigs(configs)
+}
+
+func runSeeders(repo Repository, config Config) {
+	if !config.SeedExerciseConfigs {
+		return
+	}
+	n, err := seedExerciseConfigs(repo, config.ExerciseSeedFile)
+	if err != nil {
+		log.Printf("seed: exercise configs failed: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("seed: inserted %d starter exercise configs", n)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Seconds per rep when an exercise has no tempo on record
+const defaultRepSeconds = 3
+
+// restSeconds is the recommended rest after a set: heavy low-rep work needs
+// the longest, pump work the shortest.
+func restSeconds(reps int) int {
+	switch {
+	case reps <= 5:
+		return 180
+	case reps <= 12:
+		return 120
+	}
+	return 60
+}
+
+type plannedExercise struct {
+	Exercise string `json:"exercise"`
+	Sets     int    `json:"sets"`
+	Reps     int    `json:"reps"`
+}
+
+// parsePlan reads "Squat:5x5,Bench Press:3x8" into planned exercises
+func parsePlan(raw string) ([]plannedExercise, error) {
+	var plan []plannedExercise
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.LastIndex(item, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("expected exercise:SETSxREPS, got %q", item)
+		}
+		scheme := strings.SplitN(strings.ToLower(item[i+1:]), "x", 2)
+		if len(scheme) != 2 {
+			return nil, fmt.Errorf("expected exercise:SETSxREPS, got %q", item)
+		}
+		sets, err1 := strconv.Atoi(strings.TrimSpace(scheme[0]))
+		reps, err2 := strconv.Atoi(strings.TrimSpace(scheme[1]))
+		if err1 != nil || err2 != nil || sets <= 0 || reps <= 0 {
+			return nil, fmt.Errorf("sets and reps must be positive integers in %q", item)
+		}
+		plan = append(plan, plannedExercise{Exercise: strings.TrimSpace(item[:i]), Sets: sets, Reps: reps})
+	}
+	if len(plan) == 0 {
+		return nil, fmt.Errorf("plan is required, e.g. plan=Squat:5x5,Bench Press:3x8")
+	}
+	return plan, nil
+}
+
+// GET /api/v1/session/estimate?plan=Squat:5x5,Bench Press:3x8
+// Work time uses each exercise's last logged tempo; rest follows
+// restSeconds after every set but the session's last.
+func estimateSession(repo WorkoutRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plan, err := parsePlan(c.Query("plan"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		type estimate struct {
+			plannedExercise
+			Tempo       string  `json:"tempo,omitempty"`
+			WorkSeconds int     `json:"work_seconds"`
+			RestSeconds int     `json:"rest_seconds"`
+			Minutes     float64 `json:"minutes"`
+		}
+		breakdown := make([]estimate, 0, len(plan))
+		total := 0
+		for i, p := range plan {
+			e := estimate{plannedExercise: p}
+			perSet := p.Reps * defaultRepSeconds
+			if last, ok := lastWorkout(repo, exerciseQuery(p.Exercise)); ok {
+				if tut, ok := timeUnderTension(Workout{Tempo: last.Tempo, Reps: p.Reps}); ok {
+					e.Tempo, perSet = last.Tempo, tut
+				}
+			}
+			e.WorkSeconds = perSet * p.Sets
+			rests := p.Sets
+			if i == len(plan)-1 {
+				rests--
+			}
+			e.RestSeconds = rests * restSeconds(p.Reps)
+			seconds := e.WorkSeconds + e.RestSeconds
+			e.Minutes = math.Round(float64(seconds)/6) / 10
+			total += seconds
+			breakdown = append(breakdown, e)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"minutes":   int(math.Ceil(float64(total) / 60)),
+			"seconds":   total,
+			"breakdown": breakdown,
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionName is a name the lifter gave one training day, replacing the
+// suggested one
+type SessionName struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Date      string    `gorm:"uniqueIndex" json:"date"` // 2006-01-02, see dayKey
+	Name      string    `json:"name" form:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Muscle group to session name; override with SESSION_NAMES
+var defaultSessionNames = map[string]string{
+	"chest":      "Push",
+	"shoulders":  "Push",
+	"triceps":    "Push",
+	"back":       "Pull",
+	"biceps":     "Pull",
+	"quads":      "Legs",
+	"hamstrings": "Legs",
+	"glutes":     "Legs",
+	"calves":     "Legs",
+}
+
+// A name makes the suggestion once it covers this share of the day's sets
+const sessionNameMinShare = 0.3
+
+// parseSessionNames reads "Push=chest/shoulders/triceps,Pull=back/biceps".
+// A value replaces the whole default mapping.
+func parseSessionNames(raw string, fallback map[string]string) (map[string]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return fallback, nil
+	}
+	out := map[string]string{}
+	for _, item := range strings.Split(raw, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		name, groups, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.TrimSpace(groups) == "" {
+			return nil, fmt.Errorf("expected Name=muscle/muscle, got %q", item)
+		}
+		for _, g := range strings.Split(groups, "/") {
+			g = strings.ToLower(strings.TrimSpace(g))
+			if g == "" {
+				return nil, fmt.Errorf("blank muscle group in %q", item)
+			}
+			if other, dup := out[g]; dup && other != name {
+				return nil, fmt.Errorf("%s is mapped to both %s and %s", g, other, name)
+			}
+			out[g] = name
+		}
+	}
+	return out, nil
+}
+
+// suggestSessionName names a day by the session names covering the most of
+// its sets, e.g. "Push" or "Pull + Legs"; three or more is "Full Body". A
+// day with no mapped muscle groups takes its most trained group's name.
+func suggestSessionName(groupSets map[string]int, names map[string]string) string {
+	tally := map[string]int{}
+	mapped := 0
+	for group, sets := range groupSets {
+		if name, ok := names[group]; ok {
+			tally[name] += sets
+			mapped += sets
+		}
+	}
+	if mapped == 0 {
+		return topKey(groupSets)
+	}
+
+	var dominant []string
+	for name, sets := range tally {
+		if float64(sets)/float64(mapped) >= sessionNameMinShare {
+			dominant = append(dominant, name)
+		}
+	}
+	sort.Slice(dominant, func(i, j int) bool {
+		if tally[dominant[i]] != tally[dominant[j]] {
+			return tally[dominant[i]] > tally[dominant[j]]
+		}
+		return dominant[i] < dominant[j]
+	})
+	if len(dominant) >= 3 {
+		return "Full Body"
+	}
+	return strings.Join(dominant, " + ")
+}
+
+// topKey is the key with the highest count, ties going alphabetically
+func topKey(counts map[string]int) string {
+	best := ""
+	for k, n := range counts {
+		if best == "" || n > counts[best] || (n == counts[best] && k < best) {
+			best = k
+		}
+	}
+	return best
+}
+
+type trainingSession struct {
+	Date          string         `json:"date"`
+	Sets          int            `json:"sets"`
+	MuscleGroups  map[string]int `json:"muscle_groups"` // Sets per muscle group
+	SuggestedName string         `json:"suggested_name"`
+	Name          string         `json:"name"` // The override if there is one, else the suggestion
+	Overridden    bool           `json:"overridden"`
+}
+
+// GET /api/v1/sessions[?days=N|from=...&to=...|period=...|all=true][&set_type=...|exclude_set_type=...]
+// Each training day in the window, newest first, named by the muscle groups
+// it hit. Sets without a muscle group use their exercise config's.
+func listSessions(repo WorkoutRepository, configs ExerciseConfigRepository, names SessionRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, err := parseWindow(c, config.AnalyticsLookbackDays)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		types, err := config.setTypeFilters(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		workouts, err := repo.ListWorkouts(WorkoutQuery{Filters: types, From: from, To: to})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		sessions, err := trainingSessions(workouts, configs, names, config)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, sessions)
+	}
+}
+
+// trainingSessions groups workouts into named training days, newest first
+func trainingSessions(workouts []Workout, configs ExerciseConfigRepository, names SessionRepository, config Config) ([]trainingSession, error) {
+	all, err := configs.ListExerciseConfigs()
+	if err != nil {
+		return nil, err
+	}
+	configGroups := map[string]string{}
+	for _, cfg := range all {
+		configGroups[cfg.Exercise] = cfg.MuscleGroup
+	}
+	saved, err := names.ListSessionNames()
+	if err != nil {
+		return nil, err
+	}
+	overrides := map[string]string{}
+	for _, n := range saved {
+		overrides[n.Date] = n.Name
+	}
+
+	byDay := map[string]*trainingSession{}
+	for _, w := range workouts {
+		day := dayKey(w.CreatedAt)
+		s, ok := byDay[day]
+		if !ok {
+			s = &trainingSession{Date: day, MuscleGroups: map[string]int{}}
+			byDay[day] = s
+		}
+		s.Sets++
+		group := w.MuscleGroup
+		if group == "" {
+			group = configGroups[w.Exercise]
+		}
+		if group = strings.ToLower(strings.TrimSpace(group)); group != "" {
+			s.MuscleGroups[group]++
+		}
+	}
+
+	sessions := make([]trainingSession, 0, len(byDay))
+	for _, s := range byDay {
+		s.SuggestedName = suggestSessionName(s.MuscleGroups, config.SessionNames)
+		s.Name = s.SuggestedName
+		if name, ok := overrides[s.Date]; ok {
+			s.Name, s.Overridden = name, true
+		}
+		sessions = append(sessions, *s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Date > sessions[j].Date })
+	return sessions, nil
+}
+
+// sessionDate validates the :date path parameter
+func sessionDate(c *gin.Context) (string, bool) {
+	date := c.Param("date")
+	if _, err := time.ParseInLocation("2006-01-02", date, time.Local); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date must look like 2006-01-02, got " + date})
+		return "", false
+	}
+	return date, true
+}
+
+// PUT /api/v1/sessions/:date/name {"name": "Heavy Push"}
+// Overrides the suggested name for one training day.
+func putSessionName(names SessionRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		date, ok := sessionDate(c)
+		if !ok {
+			return
+		}
+		var input SessionName
+		if err := c.ShouldBind(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		input.Name = strings.TrimSpace(input.Name)
+		if input.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be blank"})
+			return
+		}
+
+		existing, err := names.FindSessionName(date)
+		existed := err == nil
+		input.ID, input.CreatedAt = existing.ID, existing.CreatedAt
+		input.Date = date
+		if err := names.SaveSessionName(&input); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		status := http.StatusOK
+		if !existed {
+			status = http.StatusCreated
+		}
+		c.JSON(status, input)
+	}
+}
+
+// DELETE /api/v1/sessions/:date/name goes back to the suggested name
+func deleteSessionName(names SessionRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		date, ok := sessionDate(c)
+		if !ok {
+			return
+		}
+		deleted, err := names.DeleteSessionName(date)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no name set for " + date})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A run of consecutive sets of one exercise within a day
+type exerciseBlock struct {
+	Exercise string    `json:"exercise"`
+	Kind     string    `json:"kind"` // "compound" or "accessory"
+	Start    time.Time `json:"start"`
+	Sets     int       `json:"sets"`
+}
+
+type orderFinding struct {
+	Code      string   `json:"code"`
+	Message   string   `json:"message"`
+	Exercises []string `json:"exercises,omitempty"`
+}
+
+// exerciseKind calls a set compound when it's tagged so or done with a
+// barbell, and an accessory otherwise. An "accessory" or "isolation" tag
+// always wins.
+func exerciseKind(w Workout) string {
+	if w.Tags.Has("accessory") || w.Tags.Has("isolation") {
+		return "accessory"
+	}
+	if w.Tags.Has("compound") || strings.EqualFold(strings.TrimSpace(w.Equipment), "barbell") {
+		return "compound"
+	}
+	return "accessory"
+}
+
+// exerciseBlocks splits a day's sets (in time order) into runs of one
+// exercise
+func exerciseBlocks(sets []Workout) []exerciseBlock {
+	var blocks []exerciseBlock
+	for _, w := range sets {
+		if n := len(blocks); n > 0 && blocks[n-1].Exercise == w.Exercise {
+			blocks[n-1].Sets++
+			continue
+		}
+		blocks = append(blocks, exerciseBlock{Exercise: w.Exercise, Kind: exerciseKind(w), Start: w.CreatedAt, Sets: 1})
+	}
+	return blocks
+}
+
+// plateChanges counts load changes between consecutive loaded sets, and
+// the fewest the same sets could have needed: each exercise done in one run
+// with every set at a weight done together.
+func plateChanges(sets []Workout) (actual, minimum int) {
+	weights := map[string]map[Weight]bool{}
+	var order []string
+	var prev *Workout
+	for i, w := range sets {
+		if w.Weight <= 0 {
+			continue
+		}
+		if prev != nil && prev.Weight != w.Weight {
+			actual++
+		}
+		prev = &sets[i]
+		if weights[w.Exercise] == nil {
+			weights[w.Exercise] = map[Weight]bool{}
+			order = append(order, w.Exercise)
+		}
+		weights[w.Exercise][w.Weight] = true
+	}
+	for _, exercise := range order {
+		minimum += len(weights[exercise]) - 1
+	}
+	// Moving between exercises at different loads is unavoidable
+	minimum += len(order) - 1
+	if minimum < 0 {
+		minimum = 0
+	}
+	if minimum > actual {
+		minimum = actual
+	}
+	return actual, minimum
+}
+
+// analyzeOrder is the advisory findings for one day's sets
+func analyzeOrder(sets []Workout) ([]exerciseBlock, []orderFinding, int, int) {
+	blocks := exerciseBlocks(sets)
+	findings := []orderFinding{}
+
+	lastCompound := -1
+	for i, b := range blocks {
+		if b.Kind == "compound" {
+			lastCompound = i
+		}
+	}
+	var early []string
+	seen := map[string]bool{}
+	for _, b := range blocks[:lastCompound+1] {
+		if b.Kind == "accessory" && !seen[b.Exercise] {
+			early = append(early, b.Exercise)
+			seen[b.Exercise] = true
+		}
+	}
+	if len(early) > 0 {
+		findings = append(findings, orderFinding{
+			Code:      "accessories_before_compounds",
+			Message:   "Accessories were logged before compounds; doing compounds first keeps them fresh for the heaviest work",
+			Exercises: early,
+		})
+	}
+
+	runs := map[string]int{}
+	var split []string
+	for _, b := range blocks {
+		if runs[b.Exercise]++; runs[b.Exercise] == 2 {
+			split = append(split, b.Exercise)
+		}
+	}
+	if len(split) > 0 {
+		findings = append(findings, orderFinding{
+			Code:      "exercise_split",
+			Message:   "Sets of these exercises were spread across the session; fine for supersets, otherwise finishing each in one run saves setup",
+			Exercises: split,
+		})
+	}
+
+	actual, minimum := plateChanges(sets)
+	if actual > minimum {
+		findings = append(findings, orderFinding{
+			Code:    "extra_plate_changes",
+			Message: fmt.Sprintf("%d load changes where %d would do; grouping sets of the same weight saves plate swaps", actual, minimum),
+		})
+	}
+	return blocks, findings, actual, minimum
+}
+
+// GET /api/v1/session/:date/order-analysis
+// Coaching-style observations on the order a day's sets were logged in:
+// compounds after accessories, exercises split up, avoidable plate changes.
+// Advisory only; intentional supersets or pre-exhaust will show up too.
+func getOrderAnalysis(repo WorkoutRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		date := c.Param("date")
+		from, err := parseWindowTime(date, false)
+		if err != nil || len(date) != len("2006-01-02") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must look like 2006-01-02, got " + date})
+			return
+		}
+		to, _ := parseWindowTime(date, true)
+
+		sets, err := repo.ListWorkouts(WorkoutQuery{From: from, To: to})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(sets) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no sets logged on " + date})
+			return
+		}
+
+		blocks, findings, actual, minimum := analyzeOrder(sets)
+		c.JSON(http.StatusOK, gin.H{
+			"date":     date,
+			"order":    blocks,
+			"findings": findings,
+			"plate_changes": gin.H{
+				"actual":  actual,
+				"minimum": minimum,
+			},
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A session is every set of one exercise logged on the same calendar day
+type exerciseSession struct {
+	Date      string    `json:"date"`
+	Start     time.Time `json:"start"`
+	Sets      []Workout `json:"-"`
+	BestOneRM float64   `json:"best_1rm"`
+}
+
+// sessionsByExercise groups workouts (in ascending time order) into
+// per-exercise sessions, oldest first. Variations are keyed apart, see
+// trackedName.
+func sessionsByExercise(workouts []Workout) map[string][]exerciseSession {
+	out := map[string][]exerciseSession{}
+	for _, w := range workouts {
+		day := dayKey(w.CreatedAt)
+		sessions := out[trackedName(w)]
+		if n := len(sessions); n == 0 || sessions[n-1].Date != day {
+			sessions = append(sessions, exerciseSession{Date: day, Start: w.CreatedAt})
+		}
+		s := &sessions[len(sessions)-1]
+		s.Sets = append(s.Sets, w)
+		if e1rm := workoutOneRM(w); e1rm > s.BestOneRM {
+			s.BestOneRM = e1rm
+		}
+		out[trackedName(w)] = sessions
+	}
+	return out
+}
+
+type stalledExercise struct {
+	Exercise        string `json:"exercise"`
+	BestOneRM       Weight `json:"best_1rm"`
+	LastImproved    string `json:"last_improved"`
+	DaysStalled     int    `json:"days_stalled"`
+	SessionsSince   int    `json:"sessions_since_improvement"`
+	RecentBestOneRM Weight `json:"recent_best_1rm"`
+}
+
+// GET /api/v1/stalled?sessions=3
+// An exercise is stalled when none of its last N sessions beat the best
+// estimated 1RM from before them. Only sessions in the window (by default
+// ANALYTICS_LOOKBACK_DAYS) count, as of its end.
+func getStalled(repo WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n, err := strconv.Atoi(c.DefaultQuery("sessions", "3"))
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sessions must be a positive integer"})
+			return
+		}
+		from, to, err := parseWindow(c, config.AnalyticsLookbackDays)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		workouts, err := repo.ListWorkouts(WorkoutQuery{From: from, To: to})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, stalledExercises(workouts, n, to))
+	}
+}
+
+// stalledExercises applies the stalled rule to workouts (in ascending time
+// order), longest stalled first
+func stalledExercises(workouts []Workout, n int, now time.Time) []stalledExercise {
+	stalled := []stalledExercise{}
+	for exercise, sessions := range sessionsByExercise(workouts) {
+		if len(sessions) <= n {
+			continue // Not enough history to judge
+		}
+
+		// Find the session that set the running best
+		best, bestIdx := 0.0, 0
+		for i, s := range sessions {
+			if s.BestOneRM > best {
+				best, bestIdx = s.BestOneRM, i
+			}
+		}
+		if bestIdx >= len(sessions)-n {
+			continue // Improved within the last N sessions
+		}
+
+		recent := 0.0
+		for _, s := range sessions[len(sessions)-n:] {
+			if s.BestOneRM > recent {
+				recent = s.BestOneRM
+			}
+		}
+		stalled = append(stalled, stalledExercise{
+			Exercise:        exercise,
+			BestOneRM:       Weight(best),
+			LastImproved:    sessions[bestIdx].Date,
+			DaysStalled:     int(now.Sub(sessions[bestIdx].Start).Hours() / 24),
+			SessionsSince:   len(sessions) - 1 - bestIdx,
+			RecentBestOneRM: Weight(recent),
+		})
+	}
+
+	sort.Slice(stalled, func(i, j int) bool {
+		if stalled[i].DaysStalled != stalled[j].DaysStalled {
+			return stalled[i].DaysStalled > stalled[j].DaysStalled
+		}
+		return stalled[i].Exercise < stalled[j].Exercise
+	})
+	return stalled
+}
+
+// GET /api/v1/weekly-exercise-sets?week=2024-W23
+// Counts working sets per exercise for one ISO week (default: this week).
+func getWeeklyExerciseSets(repo WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start, err := parseISOWeek(c.Query("week"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		end := start.AddDate(0, 0, 7)
+		types, err := config.setTypeFilters(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rollups, err := repo.ListWeeklySummaries(start, end.Add(-time.Nanosecond))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		counts := map[string]int{}
+		for _, r := range filterRollups(rollups, types) {
+			if r.WorkingSets > 0 {
+				counts[r.Exercise] += r.WorkingSets
+			}
+		}
+
+		year, week := start.ISOWeek()
+		c.JSON(http.StatusOK, gin.H{
+			"week":      fmt.Sprintf("%d-W%02d", year, week),
+			"start":     start,
+			"end":       end,
+			"exercises": counts,
+		})
+	}
+}
+
+// GET /api/v1/sparkline?exercise=Bench&points=10
+// The best estimated 1RM of each of the last N sessions, oldest first, as a
+// bare array for tiny inline charts.
+func getSparkline(repo WorkoutRepository, configs ExerciseConfigRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exercise := canonicalExercise(c, configs, c.Query("exercise"))
+		if exercise == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exercise is required"})
+			return
+		}
+		n, err := strconv.Atoi(c.DefaultQuery("points", "10"))
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "points must be a positive integer"})
+			return
+		}
+		workouts, err := repo.ListWorkouts(exerciseQuery(exercise))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		sessions := sessionsByExercise(workouts)[exercise]
+		if len(sessions) > n {
+			sessions = sessions[len(sessions)-n:]
+		}
+		points := make([]Weight, len(sessions))
+		for i, s := range sessions {
+			points[i] = Weight(s.BestOneRM)
+		}
+		c.JSON(http.StatusOK, points)
+	}
+}
+
+// GET /api/v1/compare-exercises?a=Low-Bar-Squat&b=High-Bar-Squat
+// Both exercises' session-best estimated 1RM on one shared date axis, for
+// overlaying. Names and aliases resolve to the configured exercise, and
+// its variations count toward it. A day only one of them was trained has
+// null for the other.
+func compareExercises(repo WorkoutRepository, configs ExerciseConfigRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		a := canonicalExercise(c, configs, c.Query("a"))
+		b := canonicalExercise(c, configs, c.Query("b"))
+		if a == "" || b == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a and b are required"})
+			return
+		}
+
+		byDay := map[string]map[string]Weight{}
+		for _, exercise := range []string{a, b} {
+			sets, err := repo.ListWorkouts(exerciseQuery(exercise))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			for _, w := range sets {
+				day := dayKey(w.CreatedAt)
+				if byDay[day] == nil {
+					byDay[day] = map[string]Weight{}
+				}
+				if e1rm := Weight(workoutOneRM(w)); e1rm > byDay[day][exercise] {
+					byDay[day][exercise] = e1rm
+				}
+			}
+		}
+		dates := make([]string, 0, len(byDay))
+		for d := range byDay {
+			dates = append(dates, d)
+		}
+		sort.Strings(dates)
+
+		seriesA, seriesB := make([]*Weight, len(dates)), make([]*Weight, len(dates))
+		for i, d := range dates {
+			if v, ok := byDay[d][a]; ok {
+				seriesA[i] = &v
+			}
+			if v, ok := byDay[d][b]; ok {
+				seriesB[i] = &v
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"dates": dates,
+			"a":     gin.H{"exercise": a, "best_1rm": seriesA},
+			"b":     gin.H{"exercise": b, "best_1rm": seriesB},
+		})
+	}
+}
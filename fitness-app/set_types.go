@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Set types every set is classified as; override with SET_TYPES. Sets
+// logged without one are "working".
+var defaultSetTypes = []string{"working", "warmup", "backoff", "drop", "myo-rep", "cluster"}
+
+const defaultSetType = "working"
+
+// Warm-ups prime the lift rather than train it: they never count toward
+// volume, working sets or PRs
+const warmupSetType = "warmup"
+
+// countsForPR is whether a set can set or hold a PR
+func countsForPR(w Workout) bool {
+	return w.SetType != warmupSetType
+}
+
+// Leaves warm-ups out of a query
+var notWarmup = filterCond{Column: "set_type", Value: []string{warmupSetType}, Not: true}
+
+// parseSetTypes reads "working,warmup,backoff". The list must keep
+// "working", the type unclassified sets get.
+func parseSetTypes(raw string, fallback []string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return fallback, nil
+	}
+	var types []string
+	seen := map[string]bool{}
+	for _, t := range strings.Split(raw, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+	if !seen[defaultSetType] {
+		return nil, fmt.Errorf("must include %q", defaultSetType)
+	}
+	return types, nil
+}
+
+// normalizeSetType lowercases t, defaults it to "working" and checks it
+// against SET_TYPES
+func (c Config) normalizeSetType(t string) (string, error) {
+	t = strings.ToLower(strings.TrimSpace(t))
+	if t == "" {
+		return defaultSetType, nil
+	}
+	for _, allowed := range c.SetTypes {
+		if t == allowed {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("set_type must be one of %s, got %q", strings.Join(c.SetTypes, ", "), t)
+}
+
+// setTypeFilters reads ?set_type=working,backoff (only these) and
+// ?exclude_set_type=warmup (all but these), for the workout list and the
+// analytics that take a window. An empty value is no filter.
+func (c Config) setTypeFilters(query map[string][]string) ([]filterCond, error) {
+	var conds []filterCond
+	for _, p := range []struct {
+		param string
+		not   bool
+	}{{"set_type", false}, {"exclude_set_type", true}} {
+		values, ok := query[p.param]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		var types []string
+		for _, raw := range strings.Split(values[0], ",") {
+			if strings.TrimSpace(raw) == "" {
+				continue
+			}
+			t, err := c.normalizeSetType(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", p.param, err)
+			}
+			types = append(types, t)
+		}
+		if len(types) > 0 {
+			conds = append(conds, filterCond{Column: "set_type", Value: types, Not: p.not})
+		}
+	}
+	return conds, nil
+}
+
+// setTypeAllowed applies setTypeFilters' conditions to one set type, for
+// rows that are already aggregated such as the weekly rollups
+func setTypeAllowed(setType string, conds []filterCond) bool {
+	for _, f := range conds {
+		if f.Column == "set_type" && f.matches(setType) == f.Not {
+			return false
+		}
+	}
+	return true
+}
+
+// filterRollups keeps the rollup rows whose set type passes conds
+func filterRollups(rows []WeeklySummary, conds []filterCond) []WeeklySummary {
+	if len(conds) == 0 {
+		return rows
+	}
+	kept := rows[:0:0]
+	for _, r := range rows {
+		if setTypeAllowed(r.SetType, conds) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExerciseStat is a derived per-exercise summary kept up to date by the
+// Workout hooks, so analytics can read one row instead of scanning every set.
+// It can always be rebuilt from workouts.
+type ExerciseStat struct {
+	Exercise      string    `gorm:"primaryKey" json:"exercise"`
+	BestOneRM     float64   `json:"best_1rm"`
+	BestWorkoutID uint      `json:"best_workout_id"`
+	BestWeight    Weight    `json:"best_weight"`
+	BestReps      int       `json:"best_reps"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func findExerciseStat(repo WorkoutRepository, exercise string) (ExerciseStat, bool) {
+	stat, err := repo.FindExerciseStat(exercise)
+	return stat, err == nil
+}
+
+// bestSet returns the set with the highest estimated 1RM for an exercise
+func bestSet(repo WorkoutRepository, exercise string) (Workout, bool) {
+	stat, ok := findExerciseStat(repo, exercise)
+	if !ok {
+		return Workout{}, false
+	}
+	w, err := repo.GetWorkout(stat.BestWorkoutID)
+	return w, err == nil
+}
+
+// oneRMSet picks the set to estimate an exercise's 1RM from: its best AMRAP
+// set when it has any, since an all-out set says more than a straight set
+// stopped short, else its best set. The string names which.
+func oneRMSet(repo WorkoutRepository, exercise string) (Workout, string, bool) {
+	q := exerciseQuery(exercise)
+	q.Filters = append(q.Filters, filterCond{Column: "is_amrap", Value: true})
+	if amraps, err := repo.ListWorkouts(q); err == nil && len(amraps) > 0 {
+		best := amraps[0]
+		for _, w := range amraps[1:] {
+			if workoutOneRM(w) > workoutOneRM(best) {
+				best = w
+			}
+		}
+		return best, "amrap", true
+	}
+	w, ok := bestSet(repo, exercise)
+	return w, "best_set", ok
+}
+
+func statFromWorkout(w Workout) ExerciseStat {
+	return ExerciseStat{
+		Exercise:      w.Exercise,
+		BestOneRM:     workoutOneRM(w),
+		BestWorkoutID: w.ID,
+		BestWeight:    w.Weight,
+		BestReps:      w.Reps,
+	}
+}
+
+// rebuildExerciseStat recomputes one exercise's row from its sets and any
+// archived history
+func rebuildExerciseStat(tx *gorm.DB, exercise string) error {
+	archive, err := findArchive(tx, exercise)
+	if err != nil {
+		return err
+	}
+	var best Workout
+	err = tx.Where("exercise = ? AND set_type <> ?", exercise, warmupSetType).Order(epleySQL + " desc, created_at asc").First(&best).Error
+	if err == gorm.ErrRecordNotFound && archive.Sets == 0 {
+		return tx.Where("exercise = ?", exercise).Delete(&ExerciseStat{}).Error
+	}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	stat := archive.stat()
+	if err == nil && workoutOneRM(best) > archive.BestOneRM {
+		stat = statFromWorkout(best)
+	}
+	return tx.Save(&stat).Error
+}
+
+// A new set can only improve the best, so the upsert only overwrites when
+// the incoming estimate is higher.
+func (w *Workout) AfterCreate(tx *gorm.DB) error {
+	config := hookConfig(tx)
+	if !countsForPR(*w) {
+		return addToWeeklySummary(tx, *w, config)
+	}
+	stat := statFromWorkout(*w)
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "exercise"}},
+		DoUpdates: clause.AssignmentColumns([]string{"best_one_rm", "best_workout_id", "best_weight", "best_reps", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "exercise_stats.best_one_rm < excluded.best_one_rm"},
+		}},
+	}).Create(&stat).Error
+	if err != nil {
+		return err
+	}
+	return addToWeeklySummary(tx, *w, config)
+}
+
+// Editing or deleting a set can change which later sets were PRs and what
+// the best set is.
+func (w *Workout) AfterUpdate(tx *gorm.DB) error {
+	return w.afterChange(tx)
+}
+
+func (w *Workout) AfterDelete(tx *gorm.DB) error {
+	return w.afterChange(tx)
+}
+
+func (w *Workout) afterChange(tx *gorm.DB) error {
+	if w.Exercise == "" {
+		return nil
+	}
+	config := hookConfig(tx)
+	if err := recomputePRs(tx, w.Exercise, config); err != nil {
+		return err
+	}
+	if err := rebuildExerciseStat(tx, w.Exercise); err != nil {
+		return err
+	}
+	return refreshWeeklySummaries(tx, w.CreatedAt, w.CreatedAt, config)
+}
+
+// POST /api/v1/maintenance/rebuild-stats
+func rebuildStats(repo WorkoutRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		exercises, err := repo.RebuildExerciseStats()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"exercises": exercises, "duration_ms": time.Since(start).Milliseconds()})
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tags are stored as a comma-separated text column and exposed as a list
+type Tags []string
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func parseTags(raw string) Tags {
+	tags := Tags{}
+	for _, t := range strings.Split(raw, ",") {
+		if t = normalizeTag(t); t != "" && !tags.Has(t) {
+			tags = append(tags, t)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (t Tags) Has(tag string) bool {
+	for _, existing := range t {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Tags) Value() (driver.Value, error) {
+	return strings.Join(t, ","), nil
+}
+
+func (t *Tags) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*t = Tags{}
+	case string:
+		*t = parseTags(v)
+	case []byte:
+		*t = parseTags(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into Tags", src)
+	}
+	return nil
+}
+
+func (Tags) GormDataType() string {
+	return "text"
+}
+
+// UnmarshalParam lets forms send tags as "compound,heavy"
+func (t *Tags) UnmarshalParam(param string) error {
+	*t = parseTags(param)
+	return nil
+}
+
+// JSON clients may send either a list or the comma-separated form
+func (t *Tags) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*t = parseTags(strings.Join(list, ","))
+		return nil
+	}
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("tags must be a list or comma-separated string")
+	}
+	*t = parseTags(raw)
+	return nil
+}
+
+type tagApplyRequest struct {
+	Tag    string            `json:"tag" binding:"required"`
+	Op     string            `json:"op" binding:"required,oneof=add remove"`
+	Filter map[string]string `json:"filter"`
+}
+
+// POST /api/v1/tags/apply
+// {"tag": "compound", "op": "add", "filter": {"exercise": "Bench"}}
+func applyTags(repo WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req tagApplyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		tag := normalizeTag(req.Tag)
+		if tag == "" || strings.Contains(tag, ",") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tag must be non-empty and contain no commas"})
+			return
+		}
+
+		query := map[string][]string{}
+		for k, v := range req.Filter {
+			query[k] = []string{v}
+		}
+		filters, err := parseFilters(query, config)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Retagging the whole history by accident is too easy without this
+		if len(filters) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a filter is required (exercise, variation, muscle_group, equipment, is_failure, is_amrap or set_type)"})
+			return
+		}
+
+		workouts, err := repo.ListWorkouts(WorkoutQuery{Filters: filters})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		updates := map[uint]Tags{}
+		for _, w := range workouts {
+			switch {
+			case req.Op == "add" && !w.Tags.Has(tag):
+				updates[w.ID] = parseTags(strings.Join(append(w.Tags, tag), ","))
+			case req.Op == "remove" && w.Tags.Has(tag):
+				updated := Tags{}
+				for _, t := range w.Tags {
+					if t != tag {
+						updated = append(updated, t)
+					}
+				}
+				updates[w.ID] = updated
+			}
+		}
+		if err := repo.UpdateTags(updates); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"tag": tag, "op": req.Op, "affected": len(updates)})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
+)
+
+// targetCache coalesces /target requests: concurrent identical ones run
+// once, and a result is reused for a short window after. Any write bumps the
+// generation, so nothing computed before a write is served after it.
+type targetCache struct {
+	window time.Duration
+	flight singleflight.Group
+
+	mu      sync.Mutex
+	gen     uint64
+	entries map[string]cachedTarget
+}
+
+type cachedTarget struct {
+	resp gin.H
+	at   time.Time
+}
+
+func newTargetCache(window time.Duration) *targetCache {
+	return &targetCache{window: window, entries: map[string]cachedTarget{}}
+}
+
+func (t *targetCache) get(exercise string, rounding roundingMode, compute func() gin.H) gin.H {
+	t.mu.Lock()
+	gen := t.gen
+	key := fmt.Sprintf("%d|%s|%s", gen, rounding, exercise)
+	if e, ok := t.entries[key]; ok && time.Since(e.at) < t.window {
+		t.mu.Unlock()
+		return e.resp
+	}
+	t.mu.Unlock()
+
+	v, _, _ := t.flight.Do(key, func() (interface{}, error) {
+		resp := compute()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.window > 0 && t.gen == gen {
+			now := time.Now()
+			// Autocomplete asks for every prefix typed, so expire as we go
+			for k, e := range t.entries {
+				if now.Sub(e.at) >= t.window {
+					delete(t.entries, k)
+				}
+			}
+			t.entries[key] = cachedTarget{resp: resp, at: now}
+		}
+		return resp, nil
+	})
+	return v.(gin.H)
+}
+
+// invalidate drops every cached target
+func (t *targetCache) invalidate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.gen++
+	t.entries = map[string]cachedTarget{}
+}
+
+// invalidateOnWrite clears the cache once any write request has finished,
+// since sets, configs and goals all feed into targets
+func (t *targetCache) invalidateOnWrite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+		t.invalidate()
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseTempo reads a tempo like "3-0-1", "3-1-1-0" or "31X0" into seconds
+// per phase. "X" (explosive) counts as zero seconds.
+func parseTempo(tempo string) ([]int, bool) {
+	tempo = strings.ToUpper(strings.TrimSpace(tempo))
+	if tempo == "" {
+		return nil, false
+	}
+
+	var parts []string
+	if strings.ContainsAny(tempo, "-/:. ") {
+		parts = strings.FieldsFunc(tempo, func(r rune) bool { return strings.ContainsRune("-/:. ", r) })
+	} else {
+		parts = strings.Split(tempo, "")
+	}
+	if len(parts) < 3 || len(parts) > 4 {
+		return nil, false
+	}
+
+	phases := make([]int, 0, len(parts))
+	for _, p := range parts {
+		if p == "X" {
+			phases = append(phases, 0)
+			continue
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		phases = append(phases, n)
+	}
+	return phases, true
+}
+
+// timeUnderTension is the seconds a set spent under load, if its tempo was logged
+func timeUnderTension(w Workout) (int, bool) {
+	phases, ok := parseTempo(w.Tempo)
+	if !ok {
+		return 0, false
+	}
+	perRep := 0
+	for _, p := range phases {
+		perRep += p
+	}
+	return perRep * w.Reps, true
+}
+
+// GET /api/v1/tut?exercise=Squat (plus the usual window and set_type params)
+func getTUT(repo WorkoutRepository, configs ExerciseConfigRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, err := parseWindow(c, config.AnalyticsLookbackDays)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		types, err := config.setTypeFilters(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		q := WorkoutQuery{From: from, To: to}
+		exercise := canonicalExercise(c, configs, c.Query("exercise"))
+		if exercise != "" {
+			q = exerciseQuery(exercise)
+			q.From, q.To = from, to
+		}
+		q.Filters = append(q.Filters, types...)
+		workouts, err := repo.ListWorkouts(q)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		type point struct {
+			Date    string `json:"date"`
+			Seconds int    `json:"seconds"`
+		}
+		var series []point
+		total, sets := 0, 0
+		for _, w := range workouts {
+			tut, ok := timeUnderTension(w)
+			if !ok {
+				continue
+			}
+			total += tut
+			sets++
+			day := dayKey(w.CreatedAt)
+			if n := len(series); n > 0 && series[n-1].Date == day {
+				series[n-1].Seconds += tut
+			} else {
+				series = append(series, point{Date: day, Seconds: tut})
+			}
+		}
+		if series == nil {
+			series = []point{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"exercise":      exercise,
+			"total_seconds": total,
+			"sets":          sets,
+			"series":        series,
+		})
+	}
+}
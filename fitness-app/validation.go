@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// validateWorkout normalizes free-text fields after binding, which has
+// already rejected blank names (see workoutStructLevel), and checks the
+// rest.
+func validateWorkout(w *Workout, config Config) error {
+	w.Exercise = strings.TrimSpace(w.Exercise)
+	w.Variation = strings.TrimSpace(w.Variation)
+	w.MuscleGroup = strings.TrimSpace(w.MuscleGroup)
+	w.Equipment = strings.TrimSpace(w.Equipment)
+	w.Tempo = strings.TrimSpace(w.Tempo)
+
+	if w.ForcedReps < 0 || w.PartialReps < 0 {
+		return fmt.Errorf("forced_reps and partial_reps must not be negative")
+	}
+	setType, err := config.normalizeSetType(w.SetType)
+	if err != nil {
+		return err
+	}
+	w.SetType = setType
+	return nil
+}
+
+// inferFailureRPE fills in RPE 10 for a failure set logged without one, so
+// RPE-based analytics stay complete for HIT-style logging. An explicit RPE
+// always wins.
+func inferFailureRPE(w *Workout, config Config) {
+	if !config.FailureImpliesRPE10 || !bool(w.IsFailure) || w.RPE != 0 {
+		return
+	}
+	w.RPE = 10
+	log.Printf("workout: inferred RPE 10 for failure set of %s", w.Exercise)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Warning is feedback on a write that went through but looks off. Clients
+// switch on Code; Message is for showing as-is.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	warnRecovery   = "recovery"    // Muscle group still inside its recovery window
+	warnVolumeCap  = "volume_cap"  // Weekly working sets past the muscle group's MRV
+	warnWeightJump = "weight_jump" // Much heavier than the exercise's last set
+	warnDuplicate  = "duplicate"   // Same set as the last one, moments ago
+	warnUnitMixup  = "unit_mixup"  // Looks like lbs entered as kg, or the reverse
+)
+
+// Unit check: compare against the median of this many recent sets, needing
+// at least unitCheckMinSets, and flag values within unitCheckTolerance of
+// a kg/lbs conversion away from it
+const (
+	unitCheckRecentSets = 20
+	unitCheckMinSets    = 3
+	unitCheckTolerance  = 0.15
+)
+
+// workoutWarnings runs every soft-limit check on a set about to be saved
+func workoutWarnings(repo WorkoutRepository, config Config, w Workout, now time.Time) []Warning {
+	var warnings []Warning
+	for _, check := range []func(WorkoutRepository, Config, Workout, time.Time) (Warning, bool){
+		recoveryWarning, volumeCapWarning, weightJumpWarning, duplicateWarning, unitMixupWarning,
+	} {
+		if warning, ok := check(repo, config, w, now); ok {
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
+
+// comparableSets is what a new set is checked against: the exercise's sets
+// apart from warm-ups and the drops after a drop set's first
+func comparableSets(exercise string) WorkoutQuery {
+	q := exerciseQuery(exercise)
+	q.TopSets = true
+	return q
+}
+
+// volumeCapWarning fires once this set takes the muscle group's working
+// sets for the week past its MRV
+func volumeCapWarning(repo WorkoutRepository, config Config, w Workout, now time.Time) (Warning, bool) {
+	l, ok := config.VolumeLandmarks[strings.ToLower(w.MuscleGroup)]
+	if !ok || !config.isWorkingSet(w) {
+		return Warning{}, false
+	}
+	week, err := repo.ListWorkouts(WorkoutQuery{
+		Filters: []filterCond{{Column: "muscle_group", Value: w.MuscleGroup}},
+		From:    startOfWeek(now),
+	})
+	if err != nil {
+		return Warning{}, false
+	}
+	sets := 1
+	for _, s := range week {
+		if config.isWorkingSet(s) {
+			sets++
+		}
+	}
+	if sets <= l.MRV {
+		return Warning{}, false
+	}
+	return Warning{warnVolumeCap, fmt.Sprintf("%d working sets for %s this week, past its MRV of %d", sets, w.MuscleGroup, l.MRV)}, true
+}
+
+// weightJumpWarning catches likely typos (100 for 10.0) and reckless jumps:
+// more than WEIGHT_JUMP_WARN_PCT over the exercise's last set. Warm-ups
+// aren't checked or compared against.
+func weightJumpWarning(repo WorkoutRepository, config Config, w Workout, now time.Time) (Warning, bool) {
+	if config.WeightJumpWarnPct <= 0 || !countsForPR(w) {
+		return Warning{}, false
+	}
+	last, ok := lastWorkout(repo, comparableSets(w.Exercise))
+	if !ok || last.Weight <= 0 {
+		return Warning{}, false
+	}
+	jump := (float64(w.Weight) - float64(last.Weight)) / float64(last.Weight) * 100
+	if jump <= config.WeightJumpWarnPct {
+		return Warning{}, false
+	}
+	return Warning{warnWeightJump, fmt.Sprintf("%.1fkg is %.0f%% more than your last %s set (%.1fkg)", w.Weight, jump, w.Exercise, last.Weight)}, true
+}
+
+// duplicateWarning flags what looks like a double submit: the same weight
+// and reps as the exercise's last set within DUPLICATE_WINDOW_SECONDS
+func duplicateWarning(repo WorkoutRepository, config Config, w Workout, now time.Time) (Warning, bool) {
+	if config.DuplicateWindowSecs <= 0 || !countsForPR(w) {
+		return Warning{}, false
+	}
+	last, ok := lastWorkout(repo, comparableSets(w.Exercise))
+	if !ok || last.Weight != w.Weight || last.Reps != w.Reps {
+		return Warning{}, false
+	}
+	since := now.Sub(last.CreatedAt)
+	if since > time.Duration(config.DuplicateWindowSecs)*time.Second {
+		return Warning{}, false
+	}
+	return Warning{warnDuplicate, fmt.Sprintf("Same as the %s set logged %.0fs ago; check it wasn't a double tap", w.Exercise, since.Seconds())}, true
+}
+
+// legacyWarning is the single warning string responses carried before
+// Warnings: only the recovery check fed it
+func legacyWarning(warnings []Warning) string {
+	for _, w := range warnings {
+		if w.Code == warnRecovery {
+			return w.Message
+		}
+	}
+	return ""
+}
+
+// unitMixupWarning catches the kg/lbs mix-up at entry (225 logged for a
+// 102kg bench) when UNIT_CHECK is on: a weight about 2.2 times the usual,
+// or about 1/2.2 of it, probably went in with the wrong unit
+func unitMixupWarning(repo WorkoutRepository, config Config, w Workout, now time.Time) (Warning, bool) {
+	if !config.UnitCheck || w.Weight <= 0 || !countsForPR(w) {
+		return Warning{}, false
+	}
+	q := comparableSets(w.Exercise)
+	q.Sort, q.Limit = sortOrder{Column: "created_at", Desc: true}, unitCheckRecentSets
+	recent, err := repo.ListWorkouts(q)
+	if err != nil {
+		return Warning{}, false
+	}
+	var weights []float64
+	for _, s := range recent {
+		if s.Weight > 0 {
+			weights = append(weights, float64(s.Weight))
+		}
+	}
+	if len(weights) < unitCheckMinSets {
+		return Warning{}, false
+	}
+	sort.Float64s(weights)
+	usual := weights[len(weights)/2]
+
+	near := func(ratio, factor float64) bool {
+		return math.Abs(ratio/factor-1) <= unitCheckTolerance
+	}
+	ratio := float64(w.Weight) / usual
+	switch {
+	case near(ratio, 1/kgPerLb):
+		return Warning{warnUnitMixup, fmt.Sprintf("%.1fkg is about 2.2x your usual %s weight (%.1fkg); if that was lbs, it's %.1fkg", w.Weight, w.Exercise, usual, float64(w.Weight)*kgPerLb)}, true
+	case near(ratio, kgPerLb):
+		return Warning{warnUnitMixup, fmt.Sprintf("%.1fkg is under half your usual %s weight (%.1fkg); if it was converted from lbs twice, it's %.1fkg", w.Weight, w.Exercise, usual, float64(w.Weight)/kgPerLb)}, true
+	}
+	return Warning{}, false
+}
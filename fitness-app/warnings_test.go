@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWarningsSkipWarmupsAndDrops(t *testing.T) {
+	tests := []struct {
+		name   string
+		before gin.H
+		set    gin.H
+		want   []string
+	}{
+		{
+			name:   "working set after a warm-up",
+			before: gin.H{"exercise": "Squat", "weight": 60, "reps": 5, "set_type": "warmup"},
+			set:    gin.H{"exercise": "Squat", "weight": 100, "reps": 5},
+		},
+		{
+			name: "set after a drop set compares against its top set",
+			before: gin.H{"exercise": "Squat", "weight": 100, "reps": 8,
+				"drops": []gin.H{{"weight": 80, "reps": 6}, {"weight": 60, "reps": 5}}},
+			set: gin.H{"exercise": "Squat", "weight": 90, "reps": 8},
+		},
+		{
+			name:   "a real jump still warns",
+			before: gin.H{"exercise": "Squat", "weight": 60, "reps": 5},
+			set:    gin.H{"exercise": "Squat", "weight": 100, "reps": 5},
+			want:   []string{warnWeightJump},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t, nil)
+			if rec := app.do(http.MethodPost, "/api/v1/workout", tt.before); rec.Code != http.StatusCreated {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+			rec := app.do(http.MethodPost, "/api/v1/workout", tt.set)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+			var got workoutResponse
+			decode(t, rec, &got)
+			var codes []string
+			for _, w := range got.Warnings {
+				codes = append(codes, w.Code)
+			}
+			if !slices.Equal(codes, tt.want) {
+				t.Errorf("warnings = %v, want %v", codes, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
+)
+
+// How a program lift (one with a training max) fared in the week
+type programLift struct {
+	Lift        string `json:"lift"`
+	TrainingMax Weight `json:"training_max"`
+	Sets        int    `json:"sets"`
+	Trained     bool   `json:"trained"`
+}
+
+type weekVolume struct {
+	Sets      int            `json:"sets"`
+	WorkSets  int            `json:"working_sets"`
+	Tonnage   float64        `json:"volume_kg"`
+	Muscles   []muscleStatus `json:"muscles"`
+	Untracked []string       `json:"untracked"`
+}
+
+type weekCompliance struct {
+	Lifts          []programLift `json:"lifts"`
+	LiftsTrained   int           `json:"lifts_trained"`
+	MusclesInRange int           `json:"muscles_in_range"`
+	MusclesBelow   []string      `json:"muscles_below_mev"`
+	MusclesAbove   []string      `json:"muscles_above_mrv"`
+}
+
+type weekReview struct {
+	Week       string            `json:"week"`
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end"`
+	Sessions   []trainingSession `json:"sessions"`
+	Volume     weekVolume        `json:"volume"`
+	PRs        []Workout         `json:"prs"`
+	Compliance weekCompliance    `json:"compliance"`
+	Stalled    []stalledExercise `json:"stalled"`
+	// Sections whose queries failed, by JSON name; they come back empty
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// GET /api/v1/week/:week/review[?sessions=3][&set_type=...], also /api/v1/week/review
+// Everything for reviewing one ISO week (2024-W23, or "current", the
+// default) in one call: its named sessions, volume against the landmarks,
+// all-time PRs set, which program lifts got trained, and the week's
+// exercises that are stalled as of its end. A quiet week has every section,
+// empty.
+//
+// Sessions, compliance and stalled each need their own queries; they run
+// side by side, at most ANALYTICS_CONCURRENCY at once. One failing leaves
+// its section empty and named in errors, rather than failing the review.
+func getWeekReview(repo WorkoutRepository, configs ExerciseConfigRepository, names SessionRepository, program ProgramRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		param := c.Param("week")
+		if param == "current" {
+			param = ""
+		}
+		start, err := parseISOWeek(param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		n, err := strconv.Atoi(c.DefaultQuery("sessions", "3"))
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sessions must be a positive integer"})
+			return
+		}
+		end := start.AddDate(0, 0, 7)
+		types, err := config.setTypeFilters(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Every section builds on the week's sets, so without them there is
+		// nothing to review
+		week, err := repo.ListWorkouts(WorkoutQuery{Filters: types, From: start, To: end.Add(-time.Nanosecond)})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		year, isoWeek := start.ISOWeek()
+		review := weekReview{
+			Week:     fmt.Sprintf("%d-W%02d", year, isoWeek),
+			Start:    start,
+			End:      end,
+			Sessions: []trainingSession{},
+			PRs:      []Workout{},
+			Stalled:  []stalledExercise{},
+		}
+
+		// Volume comes from the rollups, which also count pruned sets
+		rollups, err := repo.ListWeeklySummaries(start, end.Add(-time.Nanosecond))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		rollups = filterRollups(rollups, types)
+		for _, r := range rollups {
+			review.Volume.Sets += r.Sets
+			review.Volume.WorkSets += r.WorkingSets
+			review.Volume.Tonnage += r.Volume
+		}
+		review.Volume.Muscles, review.Volume.Untracked = config.muscleStatuses(rollups)
+
+		trained := map[string]int{}
+		for _, w := range week {
+			trained[trackedName(w)]++
+			if w.IsPR {
+				review.PRs = append(review.PRs, w)
+			}
+		}
+
+		review.Compliance = weekCompliance{Lifts: []programLift{}, MusclesBelow: []string{}, MusclesAbove: []string{}}
+		for _, m := range review.Volume.Muscles {
+			switch m.Status {
+			case "below MEV":
+				review.Compliance.MusclesBelow = append(review.Compliance.MusclesBelow, m.MuscleGroup)
+			case "above MRV":
+				review.Compliance.MusclesAbove = append(review.Compliance.MusclesAbove, m.MuscleGroup)
+			default:
+				review.Compliance.MusclesInRange++
+			}
+		}
+
+		var mu sync.Mutex
+		section := func(name string, run func() error) func() error {
+			return func() error {
+				if err := run(); err != nil {
+					mu.Lock()
+					if review.Errors == nil {
+						review.Errors = map[string]string{}
+					}
+					review.Errors[name] = err.Error()
+					mu.Unlock()
+				}
+				return nil // Never cancel the other sections
+			}
+		}
+		var g errgroup.Group
+		g.SetLimit(config.AnalyticsConcurrency)
+		g.Go(section("sessions", func() error {
+			sessions, err := trainingSessions(week, configs, names, config)
+			if err == nil {
+				review.Sessions = sessions
+			}
+			return err
+		}))
+		g.Go(section("compliance", func() error {
+			maxes, err := program.ListTrainingMaxes()
+			for _, tm := range maxes {
+				lift := programLift{Lift: tm.Lift, TrainingMax: tm.Weight, Sets: trained[tm.Lift], Trained: trained[tm.Lift] > 0}
+				if lift.Trained {
+					review.Compliance.LiftsTrained++
+				}
+				review.Compliance.Lifts = append(review.Compliance.Lifts, lift)
+			}
+			return err
+		}))
+		g.Go(section("stalled", func() error {
+			// Stalled lifts need the history before the week, as of its end
+			history, err := repo.ListWorkouts(WorkoutQuery{To: end.Add(-time.Nanosecond)})
+			if err != nil {
+				return err
+			}
+			asOf := time.Now()
+			if end.Before(asOf) {
+				asOf = end
+			}
+			for _, s := range stalledExercises(history, n, asOf) {
+				if trained[s.Exercise] > 0 {
+					review.Stalled = append(review.Stalled, s)
+				}
+			}
+			return nil
+		}))
+		g.Wait()
+
+		c.JSON(http.StatusOK, review)
+	}
+}
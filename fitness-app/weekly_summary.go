@@ -0,0 +1,339 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WeeklySummary is a derived rollup of one ISO week's sets of one exercise,
+// muscle group and set type, so the weekly charts, volume landmarks and week
+// review sum a few rows instead of reading every set. Like ExerciseStat it's
+// kept up to date on writes and can always be rebuilt from workouts and
+// WeeklyArchive; rebuild after changing WORKING_SET_MIN_RPE or the forced
+// and partial rep volumes, since the rollup was counted with the old ones.
+type WeeklySummary struct {
+	Week        string    `gorm:"primaryKey" json:"week"` // 2024-W23
+	Exercise    string    `gorm:"primaryKey" json:"exercise"`
+	MuscleGroup string    `gorm:"primaryKey" json:"muscle_group"` // Lowercased
+	SetType     string    `gorm:"primaryKey" json:"set_type"`
+	Start       time.Time `gorm:"index" json:"start"` // Monday 00:00
+	Sets        int       `json:"sets"`
+	WorkingSets int       `json:"working_sets"`
+	Volume      float64   `json:"volume"`
+	PRs         int       `json:"prs"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WeeklyArchive holds the rollup of sets the history cap pruned. Refreshes
+// and rebuilds add it back in, so pruning never shrinks a past week.
+type WeeklyArchive struct {
+	WeeklySummary
+}
+
+// Columns that identify a rollup row
+var rollupKey = []clause.Column{{Name: "week"}, {Name: "exercise"}, {Name: "muscle_group"}, {Name: "set_type"}}
+
+func (s WeeklySummary) key() string {
+	return s.Week + "|" + s.Exercise + "|" + s.MuscleGroup + "|" + s.SetType
+}
+
+// weekKey is the ISO week containing t, e.g. "2024-W23"
+func weekKey(t time.Time) string {
+	year, week := t.In(time.Local).ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// summaryOf is one set's contribution to its week
+func (c Config) summaryOf(w Workout) WeeklySummary {
+	s := WeeklySummary{
+		Week:        weekKey(w.CreatedAt),
+		Exercise:    w.Exercise,
+		MuscleGroup: strings.ToLower(w.MuscleGroup),
+		SetType:     w.SetType,
+		Start:       startOfWeek(w.CreatedAt.In(time.Local)),
+		Sets:        1,
+		Volume:      c.workoutVolume(w),
+	}
+	if s.SetType == "" {
+		s.SetType = defaultSetType
+	}
+	if c.isWorkingSet(w) {
+		s.WorkingSets = 1
+	}
+	if w.IsPR {
+		s.PRs = 1
+	}
+	return s
+}
+
+func (s *WeeklySummary) add(o WeeklySummary) {
+	s.Sets += o.Sets
+	s.WorkingSets += o.WorkingSets
+	s.Volume += o.Volume
+	s.PRs += o.PRs
+}
+
+// summarizeWeeks rolls sets up by key
+func (c Config) summarizeWeeks(workouts []Workout) map[string]WeeklySummary {
+	weeks := map[string]WeeklySummary{}
+	for _, w := range workouts {
+		c.addToWeek(weeks, w)
+	}
+	return weeks
+}
+
+func (c Config) addToWeek(weeks map[string]WeeklySummary, w Workout) {
+	addRollup(weeks, c.summaryOf(w))
+}
+
+// addRollup adds delta to its row in rollups, creating it if need be
+func addRollup(rollups map[string]WeeklySummary, delta WeeklySummary) {
+	s, ok := rollups[delta.key()]
+	if !ok {
+		s = WeeklySummary{Week: delta.Week, Exercise: delta.Exercise, MuscleGroup: delta.MuscleGroup, SetType: delta.SetType, Start: delta.Start}
+	}
+	s.add(delta)
+	rollups[s.key()] = s
+}
+
+// A rollup row that didn't match its sets; Stored is zero for a missing
+// row, Rebuilt for a stale one
+type summaryDrift struct {
+	Week        string        `json:"week"`
+	Exercise    string        `json:"exercise"`
+	MuscleGroup string        `json:"muscle_group"`
+	SetType     string        `json:"set_type"`
+	Stored      WeeklySummary `json:"stored"`
+	Rebuilt     WeeklySummary `json:"rebuilt"`
+}
+
+func driftOf(s WeeklySummary, stored, rebuilt WeeklySummary) summaryDrift {
+	return summaryDrift{Week: s.Week, Exercise: s.Exercise, MuscleGroup: s.MuscleGroup, SetType: s.SetType, Stored: stored, Rebuilt: rebuilt}
+}
+
+func (s WeeklySummary) matches(o WeeklySummary) bool {
+	return s.Sets == o.Sets && s.WorkingSets == o.WorkingSets && s.PRs == o.PRs && math.Abs(s.Volume-o.Volume) < 0.01
+}
+
+// summaryDrifts compares stored rows with freshly rebuilt ones, by key
+func summaryDrifts(stored []WeeklySummary, rebuilt map[string]WeeklySummary) []summaryDrift {
+	drifts := []summaryDrift{}
+	seen := map[string]bool{}
+	for _, s := range stored {
+		seen[s.key()] = true
+		if r := rebuilt[s.key()]; !s.matches(r) {
+			drifts = append(drifts, driftOf(s, s, r))
+		}
+	}
+	for key, r := range rebuilt {
+		if !seen[key] {
+			drifts = append(drifts, driftOf(r, WeeklySummary{}, r))
+		}
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Week+drifts[i].Exercise < drifts[j].Week+drifts[j].Exercise
+	})
+	return drifts
+}
+
+// countWeeks is how many weeks the rollup rows cover
+func countWeeks(rollups map[string]WeeklySummary) int {
+	weeks := map[string]bool{}
+	for _, s := range rollups {
+		weeks[s.Week] = true
+	}
+	return len(weeks)
+}
+
+// weekTotals sums rollup rows into one per week
+func weekTotals(rows []WeeklySummary) map[string]WeeklySummary {
+	weeks := map[string]WeeklySummary{}
+	for _, r := range rows {
+		s, ok := weeks[r.Week]
+		if !ok {
+			s = WeeklySummary{Week: r.Week, Start: r.Start}
+		}
+		s.add(r)
+		weeks[r.Week] = s
+	}
+	return weeks
+}
+
+// upsertRollup adds value's counters to the rows of table with the same key
+// in one statement
+func upsertRollup(tx *gorm.DB, table string, value interface{}) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: rollupKey,
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"sets":         gorm.Expr(table + ".sets + excluded.sets"),
+			"working_sets": gorm.Expr(table + ".working_sets + excluded.working_sets"),
+			"volume":       gorm.Expr(table + ".volume + excluded.volume"),
+			"prs":          gorm.Expr(table + ".prs + excluded.prs"),
+			"updated_at":   gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(value).Error
+}
+
+// addToWeeklySummary is the incremental path for a new set: its row's
+// counters go up in one upsert
+func addToWeeklySummary(tx *gorm.DB, w Workout, config Config) error {
+	delta := config.summaryOf(w)
+	delta.UpdatedAt = time.Now()
+	return upsertRollup(tx, "weekly_summaries", &delta)
+}
+
+// archiveWeeks adds the rollup of pruned sets to WeeklyArchive. Their rows
+// in weekly_summaries stay as they were: what's archived plus what's left
+// still adds up to them.
+func archiveWeeks(tx *gorm.DB, pruned []Workout, config Config) error {
+	now := time.Now()
+	var rows []WeeklyArchive
+	for _, s := range config.summarizeWeeks(pruned) {
+		s.UpdatedAt = now
+		rows = append(rows, WeeklyArchive{s})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return upsertRollup(tx, "weekly_archives", &rows)
+}
+
+// refreshWeeklySummaries recomputes the weeks from the one containing from
+// to the one containing to, for when sets change other than by insert (PR
+// flags rewritten, sets edited or deleted)
+func refreshWeeklySummaries(tx *gorm.DB, from, to time.Time, config Config) error {
+	start := startOfWeek(from.In(time.Local))
+	end := startOfWeek(to.In(time.Local)).AddDate(0, 0, 7)
+	var sets []Workout
+	if err := tx.Where("created_at >= ? AND created_at < ?", start, end).Find(&sets).Error; err != nil {
+		return err
+	}
+	var archived []WeeklyArchive
+	if err := tx.Where("start >= ? AND start < ?", start, end).Find(&archived).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("start >= ? AND start < ?", start, end).Delete(&WeeklySummary{}).Error; err != nil {
+		return err
+	}
+	weeks := config.summarizeWeeks(sets)
+	for _, a := range archived {
+		addRollup(weeks, a.WeeklySummary)
+	}
+	return saveWeeklySummaries(tx, weeks)
+}
+
+// rebuildRollups recreates every rollup row from the sets and the archive,
+// returning them and the stored rows that disagreed
+func rebuildRollups(tx *gorm.DB, config Config) (map[string]WeeklySummary, []summaryDrift, error) {
+	var stored []WeeklySummary
+	if err := tx.Find(&stored).Error; err != nil {
+		return nil, nil, err
+	}
+	rebuilt := map[string]WeeklySummary{}
+	var batch []Workout
+	err := tx.FindInBatches(&batch, config.DB.BatchSize, func(*gorm.DB, int) error {
+		for _, w := range batch {
+			config.addToWeek(rebuilt, w)
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	var archived []WeeklyArchive
+	if err := tx.Find(&archived).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, a := range archived {
+		addRollup(rebuilt, a.WeeklySummary)
+	}
+
+	if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&WeeklySummary{}).Error; err != nil {
+		return nil, nil, err
+	}
+	return rebuilt, summaryDrifts(stored, rebuilt), saveWeeklySummaries(tx, rebuilt)
+}
+
+func saveWeeklySummaries(tx *gorm.DB, weeks map[string]WeeklySummary) error {
+	if len(weeks) == 0 {
+		return nil
+	}
+	now := time.Now()
+	rows := make([]WeeklySummary, 0, len(weeks))
+	for _, s := range weeks {
+		s.UpdatedAt = now
+		rows = append(rows, s)
+	}
+	return tx.Create(&rows).Error
+}
+
+// One week's rollup rows added up
+type weekSummary struct {
+	Week        string    `json:"week"`
+	Start       time.Time `json:"start"`
+	Sets        int       `json:"sets"`
+	WorkingSets int       `json:"working_sets"`
+	Volume      float64   `json:"volume"`
+	PRs         int       `json:"prs"`
+}
+
+// GET /api/v1/weekly-summaries[?weeks=12][&set_type=...|&exclude_set_type=...]
+// The last N ISO weeks up to this one, oldest first, read from the rollup
+// table rather than the sets. Weeks without training come back as zeros so
+// charts get an unbroken axis.
+func listWeeklySummaries(repo WorkoutRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n, err := strconv.Atoi(c.DefaultQuery("weeks", "12"))
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weeks must be a positive integer"})
+			return
+		}
+		types, err := config.setTypeFilters(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		from := startOfWeek(time.Now()).AddDate(0, 0, -7*(n-1))
+		stored, err := repo.ListWeeklySummaries(from, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		byWeek := weekTotals(filterRollups(stored, types))
+
+		weeks := make([]weekSummary, 0, n)
+		for start := from; len(weeks) < n; start = start.AddDate(0, 0, 7) {
+			s, ok := byWeek[weekKey(start)]
+			if !ok {
+				s = WeeklySummary{Week: weekKey(start), Start: start}
+			}
+			weeks = append(weeks, weekSummary{s.Week, s.Start, s.Sets, s.WorkingSets, math.Round(s.Volume*10) / 10, s.PRs})
+		}
+		c.JSON(http.StatusOK, weeks)
+	}
+}
+
+// POST /api/v1/maintenance/rebuild-weekly-summaries
+// Recomputes every week from the sets and the pruned archive. drifted lists
+// the rollup rows that disagreed with them beforehand; it should be empty
+// unless the settings changed or rows were edited outside the app.
+func rebuildWeeklySummaries(repo WorkoutRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		weeks, drifted, err := repo.RebuildWeeklySummaries()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"weeks": weeks, "drifted": drifted, "duration_ms": time.Since(start).Milliseconds()})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Weight is stored at full precision but served rounded to WEIGHT_PRECISION
+// decimals, so conversions don't leak values like 82.49999.
+type Weight float64
+
+// Round is the weight to the given number of decimals
+func (w Weight) Round(precision int) float64 {
+	p := math.Pow(10, float64(precision))
+	return math.Round(float64(w)*p) / p
+}
+
+// MarshalJSON can't be handed the Config, so it writes the full value with
+// a bare "E0" exponent, which encoding/json never writes for other numbers.
+// roundWeights rounds those numbers as responses go out.
+func (w Weight) MarshalJSON() ([]byte, error) {
+	return append(strconv.AppendFloat(nil, float64(w), 'f', -1, 64), weightMarker...), nil
+}
+
+const weightMarker = "E0"
+
+// roundWeights rounds the weights in JSON responses to precision decimals.
+// Bodies are rewritten as they stream, so exports aren't held in memory.
+func roundWeights(precision int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &weightWriter{ResponseWriter: c.Writer, precision: precision}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		w.flushNumber()
+	}
+}
+
+// weightWriter copies JSON through, holding back each number outside a
+// string until it ends so a marked weight can be swapped for its rounding
+type weightWriter struct {
+	gin.ResponseWriter
+	precision   int
+	decided     bool
+	passthrough bool
+	inString    bool
+	escaped     bool
+	number      []byte
+}
+
+func (w *weightWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.passthrough = !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	out := make([]byte, 0, len(data))
+	for _, b := range data {
+		switch {
+		case w.inString:
+			w.inString = w.escaped || b != '"'
+			w.escaped = !w.escaped && b == '\\'
+		case strings.IndexByte("-+.0123456789eE", b) >= 0:
+			w.number = append(w.number, b)
+			continue
+		case b == '"':
+			w.inString = true
+		}
+		out = append(out, w.roundedNumber()...)
+		out = append(out, b)
+	}
+	if _, err := w.ResponseWriter.Write(out); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *weightWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// roundedNumber takes the held-back number, rounded if it's a weight
+func (w *weightWriter) roundedNumber() []byte {
+	number := w.number
+	w.number = nil
+	raw, ok := strings.CutSuffix(string(number), weightMarker)
+	if !ok {
+		return number
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return number
+	}
+	return strconv.AppendFloat(nil, Weight(v).Round(w.precision), 'f', -1, 64)
+}
+
+// flushNumber writes a number the body ended on
+func (w *weightWriter) flushNumber() {
+	if len(w.number) > 0 {
+		w.ResponseWriter.Write(w.roundedNumber())
+	}
+}
+
+// roundingMode is which way suggested weights snap to a plate increment:
+// floor stays conservative, ceil leans aggressive.
+type roundingMode string
+
+const (
+	roundNearest roundingMode = "nearest"
+	roundFloor   roundingMode = "floor"
+	roundCeil    roundingMode = "ceil"
+)
+
+func parseRoundingMode(s string) (roundingMode, error) {
+	switch mode := roundingMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case roundNearest, roundFloor, roundCeil:
+		return mode, nil
+	}
+	return "", fmt.Errorf("rounding must be nearest, floor or ceil, got %q", s)
+}
+
+// roundingFor is the request's ?rounding override, else ROUNDING_MODE
+func (config Config) roundingFor(c *gin.Context) (roundingMode, error) {
+	if raw := c.Query("rounding"); raw != "" {
+		return parseRoundingMode(raw)
+	}
+	return config.LoadRounding, nil
+}
+
+// roundToLoadable snaps a weight to a plate increment. Weights already on
+// an increment stay put in every mode, despite float error in the division.
+func (c Config) roundToLoadable(w float64, mode roundingMode) float64 {
+	return roundToIncrement(w, c.LoadIncrement, mode)
+}
+
+// roundToIncrement is roundToLoadable for any increment, e.g. fractional
+// plates
+func roundToIncrement(w, increment float64, mode roundingMode) float64 {
+	if increment <= 0 {
+		return w
+	}
+	steps := w / increment
+	const epsilon = 1e-9
+	switch mode {
+	case roundFloor:
+		steps = math.Floor(steps + epsilon)
+	case roundCeil:
+		steps = math.Ceil(steps - epsilon)
+	default:
+		steps = math.Round(steps)
+	}
+	return steps * increment
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRoundToIncrement(t *testing.T) {
+	tests := []struct {
+		name      string
+		w         float64
+		increment float64
+		nearest   float64
+		floor     float64
+		ceil      float64
+	}{
+		{"exact multiple", 100, 2.5, 100, 100, 100},
+		{"exact multiple of a fractional plate", 101.25, 1.25, 101.25, 101.25, 101.25},
+		{"float error on an exact multiple", 0.1 * 3, 0.1, 0.3, 0.3, 0.3},
+		{"half increment rounds up", 101.25, 2.5, 102.5, 100, 102.5},
+		{"half of a fractional plate", 100.25, 0.5, 100.5, 100, 100.5},
+		{"below half", 101, 2.5, 100, 100, 102.5},
+		{"above half", 102, 2.5, 102.5, 100, 102.5},
+		{"zero weight", 0, 2.5, 0, 0, 0},
+		{"zero increment leaves the weight", 101.3, 0, 101.3, 101.3, 101.3},
+		{"negative increment leaves the weight", 101.3, -2.5, 101.3, 101.3, 101.3},
+	}
+
+	for _, tt := range tests {
+		for _, c := range []struct {
+			mode roundingMode
+			want float64
+		}{{roundNearest, tt.nearest}, {roundFloor, tt.floor}, {roundCeil, tt.ceil}} {
+			t.Run(fmt.Sprintf("%s/%s", tt.name, c.mode), func(t *testing.T) {
+				if got := roundToIncrement(tt.w, tt.increment, c.mode); math.Abs(got-c.want) > 1e-9 {
+					t.Errorf("roundToIncrement(%g, %g, %s) = %g, want %g", tt.w, tt.increment, c.mode, got, c.want)
+				}
+			})
+		}
+	}
+}
+
+func TestTargetRounding(t *testing.T) {
+	// A 2.5kg step from an off-plate 101kg lands on 103.5kg, between plates
+	app := newTestApp(t, nil)
+	app.seed(Workout{Exercise: "Squat", Weight: 101, Reps: 8, IsFailure: true})
+
+	for _, tt := range []struct {
+		rounding string
+		status   int
+		weight   Weight
+	}{
+		{"", http.StatusOK, 102.5},
+		{"nearest", http.StatusOK, 102.5},
+		{"floor", http.StatusOK, 102.5},
+		{"ceil", http.StatusOK, 105},
+		{"up", http.StatusBadRequest, 0},
+	} {
+		t.Run(tt.rounding, func(t *testing.T) {
+			app.t = t
+			rec := app.do(http.MethodGet, "/api/v1/target?exercise=Squat&rounding="+tt.rounding, nil)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var got struct {
+				Weight Weight `json:"weight"`
+			}
+			decode(t, rec, &got)
+			if got.Weight != tt.weight {
+				t.Errorf("weight = %v, want %v", got.Weight, tt.weight)
+			}
+		})
+	}
+}
+
+func TestWeightPrecisionPerRouter(t *testing.T) {
+	for _, tt := range []struct {
+		precision string
+		want      string
+	}{
+		{"0", "82"},
+		{"1", "82.5"},
+		{"2", "82.46"},
+	} {
+		t.Run(tt.precision, func(t *testing.T) {
+			t.Parallel()
+			app := newTestApp(t, map[string]string{"WEIGHT_PRECISION": tt.precision, "JSON_FIELD_NAMING": "camel"})
+			app.seed(Workout{Exercise: "Bench", Weight: 82.456, Reps: 8})
+			for _, path := range []string{"/api/v1/workouts", "/api/v1/export/workouts?anonymize=true"} {
+				rec := app.do(http.MethodGet, path, nil)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("%s: status = %d: %s", path, rec.Code, rec.Body.String())
+				}
+				body := rec.Body.String()
+				field := `"weight":` + tt.want
+				if !strings.Contains(body, field+",") && !strings.Contains(body, field+"}") || strings.Contains(body, weightMarker) {
+					t.Errorf("%s: want weight %s in %s", path, tt.want, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// trainingDays returns the set of calendar days (YYYY-MM-DD) with any
+// training, strength or cardio
+func trainingDays(repo WorkoutRepository, cardio CardioRepository, from, to time.Time) (map[string]bool, error) {
+	workouts, err := repo.ListWorkouts(WorkoutQuery{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := cardio.ListCardio()
+	if err != nil {
+		return nil, err
+	}
+	days := make(map[string]bool, len(workouts)+len(entries))
+	for _, w := range workouts {
+		days[dayKey(w.CreatedAt)] = true
+	}
+	for _, e := range entries {
+		if !e.CreatedAt.Before(from) && !e.CreatedAt.After(to) {
+			days[dayKey(e.CreatedAt)] = true
+		}
+	}
+	return days, nil
+}
+
+// currentStreak counts consecutive training days ending today, or yesterday
+// if today hasn't been trained yet.
+func currentStreak(days map[string]bool, now time.Time) int {
+	day := now
+	if !days[dayKey(day)] {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for days[dayKey(day)] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// GET /api/v1/widget?metric=streak|workouts|pr[&exercise=Deadlift]
+// Returns a self-contained SVG badge for embedding with a plain <img> tag.
+// Counts cover full history unless a window is given; the streak runs back
+// from the window's end.
+func getWidget(repo WorkoutRepository, configs ExerciseConfigRepository, cardio CardioRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		metric := c.DefaultQuery("metric", "streak")
+		from, to, err := parseWindow(c, 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var label, value string
+		switch metric {
+		case "streak":
+			days, err := trainingDays(repo, cardio, from, to)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			label, value = "streak", fmt.Sprintf("%d days", currentStreak(days, to))
+		case "workouts":
+			days, err := trainingDays(repo, cardio, from, to)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			label, value = "workouts", fmt.Sprint(len(days))
+		case "pr":
+			exercise := canonicalExercise(c, configs, c.Query("exercise"))
+			if exercise == "" {
+				q := prQuery
+				q.From, q.To = from, to
+				prs, _ := repo.CountWorkouts(q)
+				label, value = "PRs", fmt.Sprint(prs)
+				break
+			}
+			best, ok := bestOneRM(repo, exercise, from)
+			if !ok {
+				c.JSON(http.StatusNotFound, gin.H{"error": "no sets logged for " + exercise})
+				return
+			}
+			label, value = exercise+" e1RM", fmt.Sprintf("%gkg", Weight(best).Round(config.WeightPrecision))
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "metric must be streak, workouts or pr"})
+			return
+		}
+
+		c.Header("Cache-Control", "public, max-age=300")
+		c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(badgeSVG(label, value)))
+	}
+}
+
+// badgeSVG renders a shields-style two-part badge. Text is escaped since
+// exercise names come from user input.
+func badgeSVG(label, value string) string {
+	// Rough width estimate for an 11px sans-serif font
+	lw, vw := 10+len(label)*7, 10+len(value)*7
+	label, value = html.EscapeString(label), html.EscapeString(value)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[3]s: %[4]s">
+<rect width="%[2]d" height="20" fill="#334155"/>
+<rect x="%[2]d" width="%[5]d" height="20" fill="#2563eb"/>
+<g fill="#fff" font-family="Verdana,sans-serif" font-size="11" text-anchor="middle">
+<text x="%[6]d" y="14">%[3]s</text>
+<text x="%[7]d" y="14">%[4]s</text>
+</g>
+</svg>`, lw+vw, lw, label, value, vw, lw/2, lw+vw/2)
+}
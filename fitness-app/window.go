@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseWindow reads the time range for analytics endpoints from one of:
+//
+//	?days=N             the last N days
+//	?from=...&to=...    dates (2006-01-02) or RFC3339 timestamps; either may be omitted
+//	?period=week|month|year   the current calendar period
+//	?all=true           full history
+//
+// With none of these the window is the last lookbackDays up to now, so
+// dashboards don't scan everything by default; 0 means full history.
+func parseWindow(c *gin.Context, lookbackDays int) (time.Time, time.Time, error) {
+	now := time.Now()
+	days, from, to, period := c.Query("days"), c.Query("from"), c.Query("to"), c.Query("period")
+	all, err := parseFlexBool(c.Query("all"))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("all: %w", err)
+	}
+
+	set := 0
+	for _, v := range []bool{days != "", from != "" || to != "", period != "", all} {
+		if v {
+			set++
+		}
+	}
+	if set > 1 {
+		return time.Time{}, time.Time{}, fmt.Errorf("use only one of days, from/to, period or all")
+	}
+
+	switch {
+	case days != "":
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("days must be a positive integer, got %q", days)
+		}
+		return now.AddDate(0, 0, -n), now, nil
+
+	case period != "":
+		switch period {
+		case "week":
+			return startOfWeek(now), now, nil
+		case "month":
+			return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), now, nil
+		case "year":
+			return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()), now, nil
+		}
+		return time.Time{}, time.Time{}, fmt.Errorf("period must be week, month or year, got %q", period)
+
+	case from != "" || to != "":
+		var start, end time.Time
+		var err error
+		if from != "" {
+			if start, err = parseWindowTime(from, false); err != nil {
+				return time.Time{}, time.Time{}, fmt.Errorf("from: %w", err)
+			}
+		}
+		end = now
+		if to != "" {
+			if end, err = parseWindowTime(to, true); err != nil {
+				return time.Time{}, time.Time{}, fmt.Errorf("to: %w", err)
+			}
+		}
+		if !start.IsZero() && end.Before(start) {
+			return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+		}
+		return start, end, nil
+	}
+
+	if all || lookbackDays == 0 {
+		return time.Time{}, now, nil
+	}
+	return now.AddDate(0, 0, -lookbackDays), now, nil
+}
+
+// parseWindowTime accepts a date or RFC3339 timestamp. A bare date used as
+// the end of a window includes the whole day.
+func parseWindowTime(s string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC3339, got %q", s)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+// dayKey is the calendar day of t in the app's timezone (TZ). tzdata is
+// embedded so this holds in the alpine image too.
+func dayKey(t time.Time) string {
+	return t.In(time.Local).Format("2006-01-02")
+}
+
+// parseISOWeek turns "2024-W23" into the Monday 00:00 starting that week.
+// An empty string means the current week.
+func parseISOWeek(s string) (time.Time, error) {
+	if s == "" {
+		return startOfWeek(time.Now()), nil
+	}
+	var year, week int
+	if _, err := fmt.Sscanf(s, "%d-W%d", &year, &week); err != nil || week < 1 || week > 53 {
+		return time.Time{}, fmt.Errorf("week must look like 2024-W23, got %q", s)
+	}
+	// Jan 4th is always in ISO week 1
+	monday := startOfWeek(time.Date(year, 1, 4, 0, 0, 0, 0, time.Local)).AddDate(0, 0, (week-1)*7)
+	if y, w := monday.ISOWeek(); y != year || w != week {
+		return time.Time{}, fmt.Errorf("%d has no week %d", year, week)
+	}
+	return monday, nil
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST /api/v1/workout[?rounding=floor]
+// Combined API/HTMX route: HTMX gets a card, everyone else JSON.
+func createWorkout(repo WorkoutRepository, configs ExerciseConfigRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req workoutRequest
+
+		// .ShouldBind detects if it's JSON or Form data automatically!
+		if err := c.ShouldBind(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		workout := req.Workout
+		if err := validateWorkout(&workout, config); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validateDrops(workout, req.Drops); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		inferFailureRPE(&workout, config)
+		rounding, err := config.roundingFor(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		cfg, _ := findExerciseConfig(configs, workout.Exercise)
+		pr := detectPR(repo, workout)
+		warnings := workoutWarnings(repo, config, workout, time.Now())
+		workout.IsPR = pr != nil && pr.Type == "all-time"
+		drops, err := createWithDrops(repo, &workout, req.Drops, config)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		capHistory(repo, workout.Exercise, config)
+
+		// Check if request is from HTMX
+		if c.GetHeader("HX-Request") == "true" {
+			if len(drops) > 0 {
+				c.Writer.Header().Set("Content-Type", "text/html")
+				c.String(http.StatusCreated, fmt.Sprintf(`
+				<div class="p-3 bg-slate-700 rounded border-l-4 border-purple-500 shadow-sm animate-pulse">
+					<div class="text-xs font-black text-purple-400 tracking-widest">DROP SET</div>
+					<span class="font-bold text-blue-400">%s</span>: %s
+				</div>`, exerciseLabel(workout, cfg), dropChainLabel(workout, drops)))
+				return
+			}
+			if pr != nil {
+				c.Writer.Header().Set("Content-Type", "text/html")
+				c.String(http.StatusCreated, prCard(workout, pr))
+				return
+			}
+			htmlSnippet := fmt.Sprintf(`
+				<div class="p-3 bg-slate-700 rounded border-l-4 border-green-500 shadow-sm animate-pulse"%s>
+					<span class="font-bold text-blue-400">%s</span>: %s reps @ %s
+				</div>`, cardAccent(cfg), exerciseLabel(workout, cfg), repsLabel(workout), weightLabel(workout))
+			c.Writer.Header().Set("Content-Type", "text/html")
+			c.String(http.StatusCreated, htmlSnippet)
+			return
+		}
+
+		// Otherwise, return JSON for standard API users
+		c.JSON(http.StatusCreated, workoutResponse{
+			Workout:       workout,
+			PR:            pr,
+			NextSetAdvice: nextSetAdvice(workout, config.exerciseTargetRPE(cfg), rounding, config),
+			Warnings:      warnings,
+			Warning:       legacyWarning(warnings),
+			Drops:         drops,
+		})
+	}
+}
+
+// GET /api/v1/workouts
+func listWorkouts(repo WorkoutRepository, configs ExerciseConfigRepository, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderBy, err := parseSort(c.Query("sort"), c.Query("order"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filters, err := parseFilters(c.Request.URL.Query(), config)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		for i, f := range filters {
+			if f.Column == "exercise" {
+				filters[i].Value = canonicalExercise(c, configs, f.Value.(string))
+			}
+		}
+
+		// The list and history feed are the whole log unless a window is asked for
+		from, to, err := parseWindow(c, 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		workouts, _ := repo.ListWorkouts(WorkoutQuery{Filters: filters, From: from, To: to, Sort: orderBy})
+
+		// If HTMX is requesting the list (initial load)
+		if c.GetHeader("HX-Request") == "true" {
+			var html string
+			drops := groupDrops(workouts)
+			all, _ := configs.ListExerciseConfigs()
+			styles := map[string]ExerciseConfig{}
+			for _, cfg := range all {
+				styles[cfg.Exercise] = cfg
+			}
+			leaders := map[uint]bool{}
+			for _, w := range workouts {
+				leaders[w.ID] = true
+			}
+			for _, w := range workouts {
+				// Drops render inside their leader's card
+				if isDropFollower(w) && leaders[w.DropSetID] {
+					continue
+				}
+				// Simple HIT Intensity indicator
+				intensityBadge := ""
+				if w.IsFailure {
+					intensityBadge = "🔥 HIT"
+				}
+				if w.IsAMRAP {
+					intensityBadge += " 🎯 AMRAP"
+				}
+				if w.SetType != "" && w.SetType != defaultSetType && w.DropSetID == 0 {
+					intensityBadge += " " + strings.ToUpper(w.SetType)
+				}
+				if w.IsPR {
+					intensityBadge += " 🏆 PR"
+				}
+				cfg := styles[w.Exercise]
+				if chain, ok := drops[w.ID]; ok {
+					html += fmt.Sprintf(`
+					<div class="p-3 bg-slate-700 rounded border-l-4 border-purple-500 mb-2"%s>
+						<div class="flex justify-between items-center">
+							<span class="font-bold text-lg">%s</span>
+							<span class="text-xs font-bold text-purple-400">DROP SET %s</span>
+						</div>
+						<div class="text-sm text-slate-300">%s</div>
+					</div>`, cardAccent(cfg), exerciseLabel(w, cfg), intensityBadge, dropChainLabel(w, chain))
+					continue
+				}
+				html += fmt.Sprintf(`
+					<div class="p-3 bg-slate-700 rounded border-l-4 border-blue-500 mb-2"%s>
+						<div class="flex justify-between items-center">
+							<span class="font-bold text-lg">%s</span>
+							<span class="text-xs font-bold text-red-500">%s</span>
+						</div>
+						<div class="text-sm text-slate-300">
+							%s reps @ %s (RPE: %d)
+						</div>
+					</div>`, cardAccent(cfg), exerciseLabel(w, cfg), intensityBadge, repsLabel(w), weightLabel(w), w.RPE)
+			}
+			c.Writer.Header().Set("Content-Type", "text/html")
+			c.String(http.StatusOK, html)
+			return
+		}
+		c.JSON(http.StatusOK, workouts)
+	}
+}
+
+// GET /api/v1/target?exercise=Squat[&variation=pause][&rounding=floor]
+// Progressive overload target for the next set, from the last set of the
+// same variation (none by default).
+//
+// With a goal for the exercise, goal_projection shows how far the suggested
+// set gets toward it and when recent pace would reach it. Identical requests
+// arriving together (autocomplete while typing) share one computation.
+func getTarget(repo WorkoutRepository, configs ExerciseConfigRepository, goals GoalRepository, cache *targetCache, config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exercise := canonicalExercise(c, configs, c.Query("exercise"))
+		variation := strings.TrimSpace(c.Query("variation"))
+		rounding, err := config.roundingFor(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		key := trackedName(Workout{Exercise: exercise, Variation: variation})
+		resp := cache.get(key, rounding, func() gin.H {
+			return computeTarget(repo, configs, goals, exercise, variation, rounding, config)
+		})
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func computeTarget(repo WorkoutRepository, configs ExerciseConfigRepository, goals GoalRepository, exercise, variation string, rounding roundingMode, config Config) gin.H {
+	cfg, _ := findExerciseConfig(configs, exercise)
+	rpe := config.exerciseTargetRPE(cfg)
+
+	// Find last log for this exercise, going by the top set of a drop set
+	// and skipping warm-ups
+	q := variationQuery(exercise, variation)
+	q.TopSets = true
+	last, ok := lastWorkout(repo, q)
+	goal, hasGoal := findGoal(goals, exercise)
+	if !ok {
+		weight, reps := config.startingTarget(cfg)
+		resp := gin.H{
+			"exercise":     exercise,
+			"variation":    variation,
+			"weight":       weight,
+			"reps":         reps,
+			"new_exercise": true,
+			"message":      "New Exercise: starting recommendation",
+			"cue":          cfg.Cue,
+			"target_rpe":   rpe,
+		}
+		if hasGoal {
+			resp["goal_projection"] = projectGoal(repo, goal, Workout{Weight: weight, Reps: reps}, time.Now())
+		}
+		return resp
+	}
+
+	// Progressive Overload Algorithm (Simple HIT)
+	targetWeight := last.Weight
+	targetReps := last.Reps
+
+	progression := decideProgression(last, cfg, rpe, config.ProgressionTieBreak)
+	switch progression.Increase {
+	case "weight":
+		targetWeight = Weight(config.roundToLoadable(float64(targetWeight+config.progressionStep(cfg)), rounding))
+	case "both":
+		targetWeight = Weight(roundToIncrement(float64(targetWeight)+config.MicroIncrement, config.MicroIncrement, rounding))
+		targetReps += 1
+	default:
+		targetReps += 1
+	}
+
+	resp := gin.H{
+		"exercise":    exercise,
+		"variation":   variation,
+		"weight":      targetWeight,
+		"reps":        targetReps,
+		"message":     fmt.Sprintf("Last: %.1fkg x %d", last.Weight, last.Reps),
+		"cue":         cfg.Cue,
+		"target_rpe":  rpe,
+		"progression": progression,
+		"change":      gin.H{"weight": targetWeight - last.Weight, "reps": targetReps - last.Reps},
+	}
+	if hasGoal {
+		next := Workout{Weight: targetWeight, Reps: targetReps, PerSide: last.PerSide}
+		resp["goal_projection"] = projectGoal(repo, goal, next, time.Now())
+	}
+	return resp
+}
+
+// How the target moves on from the last set, and the rule that decided it
+type progressionDecision struct {
+	Increase string `json:"increase"` // "weight", "reps" or "both"
+	Rule     string `json:"rule"`
+}
+
+// decideProgression picks weight or reps for the next target. A set taken
+// to failure or AMRAP (or easier than the target RPE) with 8+ reps earns
+// weight, as does reaching the top of the exercise's rep range. A hard set
+// still inside a configured range could take either; tieBreak
+// (PROGRESSION_TIE_BREAK) decides, unless the exercise microloads: then it
+// gets a rep and MICRO_INCREMENT together. Anything else adds a rep.
+func decideProgression(last Workout, cfg ExerciseConfig, rpe int, tieBreak string) progressionDecision {
+	easy := last.RPE > 0 && last.RPE < rpe
+	weightOK := (bool(last.IsFailure) || bool(last.IsAMRAP) || easy) && last.Reps >= 8
+	hasRange := cfg.RepRangeMax > 0
+	belowTop := !hasRange || last.Reps < cfg.RepRangeMax
+
+	switch {
+	case weightOK && belowTop && cfg.Progression == "microload":
+		return progressionDecision{"both", "microload"}
+	case weightOK && hasRange && belowTop:
+		if tieBreak == "reps" {
+			return progressionDecision{"reps", "tie_prefer_reps"}
+		}
+		return progressionDecision{"weight", "tie_prefer_weight"}
+	case !belowTop:
+		return progressionDecision{"weight", "rep_range_top"}
+	case weightOK:
+		return progressionDecision{"weight", "hard_set"}
+	}
+	return progressionDecision{"reps", "add_rep"}
+}
+
+// GET /api/v1/last?exercise=Squat[&variation=pause]
+// Last set for an exercise, for a one-tap "same as last time" re-log.
+func getLastWorkout(repo WorkoutRepository, configs ExerciseConfigRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exercise := canonicalExercise(c, configs, c.Query("exercise"))
+		if exercise == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exercise is required"})
+			return
+		}
+		variation := strings.TrimSpace(c.Query("variation"))
+		last, ok := lastWorkout(repo, variationQuery(exercise, variation))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no sets logged for " + trackedName(Workout{Exercise: exercise, Variation: variation})})
+			return
+		}
+		c.JSON(http.StatusOK, last)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTargetProgression(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		cfg      *ExerciseConfig
+		last     Workout
+		weight   Weight
+		reps     int
+		increase string
+		rule     string
+	}{
+		{
+			name:   "hard set without a rep range adds weight",
+			last:   Workout{Weight: 100, Reps: 8, IsFailure: true},
+			weight: 102.5, reps: 8, increase: "weight", rule: "hard_set",
+		},
+		{
+			name:   "easy set short of 8 adds a rep",
+			last:   Workout{Weight: 100, Reps: 6, RPE: 6},
+			weight: 100, reps: 7, increase: "reps", rule: "add_rep",
+		},
+		{
+			name:   "hard set inside the range is a tie, weight by default",
+			cfg:    &ExerciseConfig{RepRangeMin: 6, RepRangeMax: 12},
+			last:   Workout{Weight: 100, Reps: 8, IsFailure: true},
+			weight: 102.5, reps: 8, increase: "weight", rule: "tie_prefer_weight",
+		},
+		{
+			name:   "tie goes to reps with PROGRESSION_TIE_BREAK=reps",
+			env:    map[string]string{"PROGRESSION_TIE_BREAK": "reps"},
+			cfg:    &ExerciseConfig{RepRangeMin: 6, RepRangeMax: 12},
+			last:   Workout{Weight: 100, Reps: 8, IsFailure: true},
+			weight: 100, reps: 9, increase: "reps", rule: "tie_prefer_reps",
+		},
+		{
+			name:   "hard set at the top of the range adds weight",
+			env:    map[string]string{"PROGRESSION_TIE_BREAK": "reps"},
+			cfg:    &ExerciseConfig{RepRangeMin: 6, RepRangeMax: 10},
+			last:   Workout{Weight: 100, Reps: 10, IsFailure: true},
+			weight: 102.5, reps: 10, increase: "weight", rule: "rep_range_top",
+		},
+		{
+			name:   "reaching the top of the range adds weight, not a rep past it",
+			cfg:    &ExerciseConfig{RepRangeMin: 3, RepRangeMax: 5},
+			last:   Workout{Weight: 100, Reps: 5},
+			weight: 102.5, reps: 5, increase: "weight", rule: "rep_range_top",
+		},
+		{
+			name:   "below the range's top adds a rep",
+			cfg:    &ExerciseConfig{RepRangeMin: 3, RepRangeMax: 5},
+			last:   Workout{Weight: 100, Reps: 4},
+			weight: 100, reps: 5, increase: "reps", rule: "add_rep",
+		},
+		{
+			name:   "microloading adds a rep and the micro increment",
+			cfg:    &ExerciseConfig{RepRangeMin: 6, RepRangeMax: 12, Progression: "microload"},
+			last:   Workout{Weight: 100, Reps: 8, IsFailure: true},
+			weight: 100.5, reps: 9, increase: "both", rule: "microload",
+		},
+		{
+			name:   "microloading at the top of the range adds weight only",
+			cfg:    &ExerciseConfig{RepRangeMin: 6, RepRangeMax: 8, Progression: "microload"},
+			last:   Workout{Weight: 100, Reps: 8, IsFailure: true},
+			weight: 102.5, reps: 8, increase: "weight", rule: "rep_range_top",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t, tt.env)
+			if tt.cfg != nil {
+				tt.cfg.Exercise = "Squat"
+				if err := app.repo.SaveExerciseConfig(tt.cfg); err != nil {
+					t.Fatalf("saving config: %v", err)
+				}
+			}
+			tt.last.Exercise = "Squat"
+			app.seed(tt.last)
+
+			rec := app.do(http.MethodGet, "/api/v1/target?exercise=Squat", nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+			var got struct {
+				Weight      Weight              `json:"weight"`
+				Reps        int                 `json:"reps"`
+				Progression progressionDecision `json:"progression"`
+			}
+			decode(t, rec, &got)
+			if got.Weight != tt.weight || got.Reps != tt.reps {
+				t.Errorf("target = %vkg x %d, want %vkg x %d", got.Weight, got.Reps, tt.weight, tt.reps)
+			}
+			if got.Progression.Increase != tt.increase || got.Progression.Rule != tt.rule {
+				t.Errorf("progression = %+v, want %s/%s", got.Progression, tt.increase, tt.rule)
+			}
+		})
+	}
+}